@@ -0,0 +1,34 @@
+// Package access holds the rules of who can see what and who can log in,
+// so that the HTTP and gRPC servers apply the same ones
+package access
+
+import (
+	"context"
+
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/token"
+	"github.com/yuuban007/simplebank/util"
+)
+
+// CanViewAccount reports whether the account is visible to the authenticated user,
+// depositors can only see their own accounts while bank staff can see any account
+func CanViewAccount(authPayload *token.Payload, account db.Account) bool {
+	return account.Owner == authPayload.Username || util.IsStaffRole(authPayload.Role)
+}
+
+// CanViewTransfer reports whether one of the accounts of the transfer is visible to the authenticated user
+func CanViewTransfer(ctx context.Context, store db.Store, authPayload *token.Payload, transfer db.Transfer) (bool, error) {
+	if util.IsStaffRole(authPayload.Role) {
+		return true, nil
+	}
+	for _, accountID := range []int64{transfer.FromAccountID, transfer.ToAccountID} {
+		account, err := store.GetAccount(ctx, accountID)
+		if err != nil {
+			return false, err
+		}
+		if CanViewAccount(authPayload, account) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
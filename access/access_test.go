@@ -0,0 +1,113 @@
+package access
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/yuuban007/simplebank/db/mock"
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/token"
+	"github.com/yuuban007/simplebank/util"
+	"go.uber.org/mock/gomock"
+)
+
+func randomAccount(owner string) db.Account {
+	return db.Account{
+		ID:       util.RandomInt(1, 1000),
+		Owner:    owner,
+		Currency: util.RandomCurrency(),
+		Status:   util.AccountStatusActive,
+	}
+}
+
+func TestCanViewAccount(t *testing.T) {
+	account := randomAccount(util.RandomOwner())
+
+	require.True(t, CanViewAccount(&token.Payload{Username: account.Owner, Role: util.DepositorRole}, account))
+	require.False(t, CanViewAccount(&token.Payload{Username: util.RandomOwner(), Role: util.DepositorRole}, account))
+	require.True(t, CanViewAccount(&token.Payload{Username: util.RandomOwner(), Role: util.BankerRole}, account))
+}
+
+func TestCanViewTransfer(t *testing.T) {
+	fromAccount := randomAccount(util.RandomOwner())
+	toAccount := randomAccount(util.RandomOwner())
+	toAccount.ID = fromAccount.ID + 1
+	transfer := db.Transfer{
+		ID:            util.RandomInt(1, 1000),
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        util.RandomMoney(),
+	}
+
+	testCases := []struct {
+		name        string
+		authPayload *token.Payload
+		buildStubs  func(store *mockdb.MockStore)
+		allowed     bool
+		err         error
+	}{
+		{
+			name:        "Sender",
+			authPayload: &token.Payload{Username: fromAccount.Owner, Role: util.DepositorRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(0)
+			},
+			allowed: true,
+		},
+		{
+			name:        "Recipient",
+			authPayload: &token.Payload{Username: toAccount.Owner, Role: util.DepositorRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+			},
+			allowed: true,
+		},
+		{
+			name:        "Stranger",
+			authPayload: &token.Payload{Username: util.RandomOwner(), Role: util.DepositorRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(2).DoAndReturn(
+					func(_ context.Context, id int64) (db.Account, error) {
+						if id == fromAccount.ID {
+							return fromAccount, nil
+						}
+						return toAccount, nil
+					})
+			},
+			allowed: false,
+		},
+		{
+			name:        "Banker",
+			authPayload: &token.Payload{Username: util.RandomOwner(), Role: util.BankerRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			allowed: true,
+		},
+		{
+			name:        "StoreError",
+			authPayload: &token.Payload{Username: toAccount.Owner, Role: util.DepositorRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, db.ErrRecordNotFound)
+			},
+			err: db.ErrRecordNotFound,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			allowed, err := CanViewTransfer(context.Background(), store, tc.authPayload, transfer)
+			require.ErrorIs(t, err, tc.err)
+			require.Equal(t, tc.allowed, allowed)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/ratelimit"
+	"github.com/yuuban007/simplebank/util"
+)
+
+// ErrInvalidCredentials is returned for a wrong password and for a missing user alike
+var ErrInvalidCredentials = errors.New("incorrect username or password")
+
+// LoginLockedError is returned while a username is locked out after too many failed logins
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (err *LoginLockedError) Error() string {
+	return "too many failed logins, retry later"
+}
+
+// Authenticator checks the password of the users logging in under the login lockout
+type Authenticator struct {
+	store   db.Store
+	lockout *ratelimit.Lockout
+}
+
+// NewAuthenticator creates an Authenticator that counts the failed logins with the lockout
+func NewAuthenticator(store db.Store, lockout *ratelimit.Lockout) *Authenticator {
+	return &Authenticator{
+		store:   store,
+		lockout: lockout,
+	}
+}
+
+// Login returns the user when the password is right. It returns a LoginLockedError while the
+// username is locked out, and ErrInvalidCredentials for an unknown user or a wrong password.
+func (authenticator *Authenticator) Login(ctx context.Context, username string, password string) (db.User, error) {
+	// the attempt is counted before the password is checked, so a locked username
+	// is refused however many guesses run in parallel
+	retryAfter, locked, err := authenticator.lockout.Attempt(ctx, username)
+	if err != nil {
+		slog.WarnContext(ctx, "cannot check login lockout", "error", err)
+	}
+	if locked {
+		return db.User{}, &LoginLockedError{RetryAfter: retryAfter}
+	}
+
+	user, err := authenticator.store.GetUser(ctx, username)
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		return db.User{}, err
+	}
+
+	// a missing user and a wrong password get the same answer in the same time,
+	// so that logins don't tell which usernames exist
+	if errors.Is(err, db.ErrRecordNotFound) {
+		util.CheckDummyPassword(password)
+	} else {
+		err = util.CheckPassword(password, user.HashedPassword)
+	}
+	if err != nil {
+		return db.User{}, ErrInvalidCredentials
+	}
+
+	if err := authenticator.lockout.Succeed(ctx, username); err != nil {
+		slog.WarnContext(ctx, "cannot clear failed logins", "error", err)
+	}
+	return user, nil
+}
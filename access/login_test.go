@@ -0,0 +1,138 @@
+package access
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/yuuban007/simplebank/db/mock"
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/ratelimit"
+	"github.com/yuuban007/simplebank/util"
+	"go.uber.org/mock/gomock"
+)
+
+const testLoginMaxFailures = 3
+
+func randomUser(t *testing.T) (db.User, string) {
+	password := util.RandomString(8)
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+
+	user := db.User{
+		Username:       util.RandomOwner(),
+		Role:           util.DepositorRole,
+		HashedPassword: hashedPassword,
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	}
+	return user, password
+}
+
+func newTestAuthenticator(store db.Store) *Authenticator {
+	lockout := ratelimit.NewLockout(ratelimit.NewMemoryBackend(), testLoginMaxFailures, time.Minute)
+	return NewAuthenticator(store, lockout)
+}
+
+func TestLogin(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		password      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, loggedIn db.User, err error)
+	}{
+		{
+			name:     "OK",
+			password: password,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, loggedIn db.User, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user, loggedIn)
+			},
+		},
+		{
+			name:     "UserNotFound",
+			password: password,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, loggedIn db.User, err error) {
+				require.ErrorIs(t, err, ErrInvalidCredentials)
+				require.Empty(t, loggedIn)
+			},
+		},
+		{
+			name:     "IncorrectPassword",
+			password: util.RandomString(8),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, loggedIn db.User, err error) {
+				require.ErrorIs(t, err, ErrInvalidCredentials)
+				require.Empty(t, loggedIn)
+			},
+		},
+		{
+			name:     "StoreError",
+			password: password,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, pgx.ErrTxClosed)
+			},
+			checkResponse: func(t *testing.T, loggedIn db.User, err error) {
+				require.ErrorIs(t, err, pgx.ErrTxClosed)
+				require.NotErrorIs(t, err, ErrInvalidCredentials)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			loggedIn, err := newTestAuthenticator(store).Login(context.Background(), user.Username, tc.password)
+			tc.checkResponse(t, loggedIn, err)
+		})
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	user, password := randomUser(t)
+
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	// the locked out attempt doesn't reach the store
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(user.Username)).
+		Times(testLoginMaxFailures+2).
+		Return(user, nil)
+
+	authenticator := newTestAuthenticator(store)
+
+	// a successful login clears the failures before it
+	_, err := authenticator.Login(context.Background(), user.Username, util.RandomString(8))
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = authenticator.Login(context.Background(), user.Username, password)
+	require.NoError(t, err)
+
+	for i := 0; i < testLoginMaxFailures; i++ {
+		_, err := authenticator.Login(context.Background(), user.Username, util.RandomString(8))
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	// even the right password is refused until the lockout ends
+	_, err = authenticator.Login(context.Background(), user.Username, password)
+	var lockedErr *LoginLockedError
+	require.True(t, errors.As(err, &lockedErr))
+	require.Positive(t, lockedErr.RetryAfter)
+}
@@ -1,14 +1,13 @@
 package api
 
 import (
-	"database/sql"
-	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/lib/pq"
+	"github.com/yuuban007/simplebank/access"
 	db "github.com/yuuban007/simplebank/db/sqlc"
 	"github.com/yuuban007/simplebank/token"
+	"github.com/yuuban007/simplebank/util"
 )
 
 type createAccountRequest struct {
@@ -20,7 +19,7 @@ type createAccountRequest struct {
 func (server *Server) createAccount(ctx *gin.Context) {
 	var req createAccountRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(ctx, bindingError(err))
 		return
 	}
 
@@ -33,15 +32,8 @@ func (server *Server) createAccount(ctx *gin.Context) {
 	}
 	account, err := server.store.CreateAccount(ctx, arg)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			// log.Println(pqErr.Code.Name())
-			switch pqErr.Code.Name() {
-			case "foreign_key_violation", "unique_violation":
-				ctx.JSON(http.StatusForbidden, errorResponse(err))
-				return
-			}
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(ctx, alreadyExistsError(err, codeAccountAlreadyExists,
+			"the user already has an account in "+req.Currency))
 		return
 	}
 	ctx.JSON(http.StatusOK, account)
@@ -54,49 +46,213 @@ type getAccountRequest struct {
 func (server *Server) getAccount(ctx *gin.Context) {
 	var req getAccountRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(ctx, bindingError(err))
 		return
 	}
 	account, err := server.store.GetAccount(ctx, req.ID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(ctx, notFoundError(err, codeAccountNotFound, "account [%d] not found", req.ID))
 		return
 	}
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if account.Owner != authPayload.Username {
-		err := errors.New("account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	if !access.CanViewAccount(authPayload, account) {
+		abortWithError(ctx, errAccountNotOwned)
 		return
 	}
 	ctx.JSON(http.StatusOK, account)
 }
 
 type listAccountRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	pageRequest
+}
+
+type listAccountResponse struct {
+	Accounts   []db.Account `json:"accounts"`
+	NextCursor string       `json:"next_cursor,omitempty"`
 }
 
 func (server *Server) listAccount(ctx *gin.Context) {
 	var req listAccountRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+	page, err := server.cursors.page(cursorKindAccounts, req.pageRequest)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
 	}
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
 	arg := db.ListAccountParams{
-		Owner:  authPayload.Username,
-		Limit:  req.PageSize,
-		Offset: req.PageSize * (req.PageID - 1),
+		Owner:          authPayload.Username,
+		AfterCreatedAt: page.AfterCreatedAt,
+		AfterID:        page.AfterID,
+		Limit:          page.Limit,
+		Offset:         page.Offset,
 	}
 	accounts, err := server.store.ListAccount(ctx, arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(ctx, err)
+		return
+	}
+
+	rsp := listAccountResponse{Accounts: accounts}
+	if n := len(accounts); n > 0 {
+		last := accounts[n-1]
+		rsp.NextCursor, err = server.cursors.nextCursor(cursorKindAccounts, req.pageRequest, n, last.ID, last.CreatedAt)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type updateAccountStatusURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// freezeAccount stops money from moving in or out of an active account, only bank staff can freeze accounts
+func (server *Server) freezeAccount(ctx *gin.Context) {
+	server.updateAccountStatus(ctx, util.AccountStatusFrozen)
+}
+
+// unfreezeAccount makes a frozen account active again, only bank staff can unfreeze accounts
+func (server *Server) unfreezeAccount(ctx *gin.Context) {
+	server.updateAccountStatus(ctx, util.AccountStatusActive)
+}
+
+func (server *Server) updateAccountStatus(ctx *gin.Context, status string) {
+	var uri updateAccountStatusURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+
+	arg := db.UpdateAccountStatusTxParams{
+		AccountID: uri.ID,
+		Status:    status,
+	}
+	account, err := server.store.UpdateAccountStatusTx(ctx, arg)
+	if err != nil {
+		abortWithError(ctx, notFoundError(err, codeAccountNotFound, "account [%d] not found", uri.ID))
+		return
+	}
+	ctx.JSON(http.StatusOK, account)
+}
+
+type closeAccountURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type closeAccountRequest struct {
+	SweepAccountID int64 `json:"sweep_account_id" binding:"omitempty,min=1"`
+}
+
+// closeAccount closes an account of the authenticated user, or any account for bank staff.
+// The balance must be zero unless the request names another account of the same owner to sweep it to,
+// and only bank staff can close a frozen account.
+func (server *Server) closeAccount(ctx *gin.Context) {
+	var uri closeAccountURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+	// the body is optional, closing an account with a zero balance needs none
+	var req closeAccountRequest
+	if ctx.Request.Body != nil && ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			abortWithError(ctx, bindingError(err))
+			return
+		}
 	}
-	ctx.JSON(http.StatusOK, accounts)
+
+	account, valid := server.findAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !access.CanViewAccount(authPayload, account) {
+		abortWithError(ctx, errAccountNotOwned)
+		return
+	}
+
+	arg := db.CloseAccountTxParams{
+		AccountID:      account.ID,
+		SweepAccountID: req.SweepAccountID,
+		AllowFrozen:    util.IsStaffRole(authPayload.Role),
+	}
+	if req.SweepAccountID != 0 {
+		arg.ExchangeRate, valid = server.sweepExchangeRate(ctx, account, req.SweepAccountID)
+		if !valid {
+			return
+		}
+	}
+
+	result, err := server.store.CloseAccountTx(ctx, arg)
+	if err != nil {
+		abortWithError(ctx, notFoundError(err, codeAccountNotFound, "account not found"))
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// sweepExchangeRate checks that the sweep account belongs to the owner of the closing account,
+// and returns the rate to convert the balance to its currency when it differs
+func (server *Server) sweepExchangeRate(ctx *gin.Context, account db.Account, sweepAccountID int64) (string, bool) {
+	sweepAccount, valid := server.findAccount(ctx, sweepAccountID)
+	if !valid {
+		return "", false
+	}
+	if sweepAccount.Owner != account.Owner {
+		abortWithError(ctx, newAPIError(http.StatusUnprocessableEntity, codeInvalidSweepAccount,
+			"sweep account [%d] doesn't belong to the owner of account [%d]", sweepAccount.ID, account.ID))
+		return "", false
+	}
+	if sweepAccount.Currency == account.Currency {
+		return "", true
+	}
+
+	rate, err := server.fxRates.Rate(ctx, account.Currency, sweepAccount.Currency)
+	if err != nil {
+		abortWithError(ctx, err)
+		return "", false
+	}
+	return rate.String(), true
+}
+
+type updateOverdraftLimitURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type updateOverdraftLimitRequest struct {
+	OverdraftLimit *int64 `json:"overdraft_limit" binding:"required,min=0"`
+}
+
+func (server *Server) updateOverdraftLimit(ctx *gin.Context) {
+	var uri updateOverdraftLimitURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+	var req updateOverdraftLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+
+	arg := db.UpdateAccountOverdraftLimitParams{
+		ID:             uri.ID,
+		OverdraftLimit: *req.OverdraftLimit,
+	}
+	account, err := server.store.UpdateAccountOverdraftLimit(ctx, arg)
+	if err != nil {
+		abortWithError(ctx, notFoundError(err, codeAccountNotFound, "account [%d] not found", uri.ID))
+		return
+	}
+	ctx.JSON(http.StatusOK, account)
 }
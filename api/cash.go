@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yuuban007/simplebank/access"
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/token"
+)
+
+type cashURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type cashRequest struct {
+	Amount   int64  `json:"amount" binding:"required,gt=0"`
+	Currency string `json:"currency" binding:"required,currency"`
+}
+
+type cashResponse struct {
+	Account db.Account `json:"account"`
+	Entry   db.Entry   `json:"entry"`
+}
+
+// createDeposit adds cash to an account, only bank staff can take deposits
+func (server *Server) createDeposit(ctx *gin.Context) {
+	server.moveCash(ctx, server.store.DepositTx)
+}
+
+// createWithdrawal takes cash out of an account of the authenticated user, or any account for bank staff
+func (server *Server) createWithdrawal(ctx *gin.Context) {
+	server.moveCash(ctx, server.store.WithdrawTx)
+}
+
+func (server *Server) moveCash(ctx *gin.Context, cashTx func(context.Context, db.CashTxParams) (db.CashTxResult, error)) {
+	var uri cashURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+	var req cashRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+
+	account, valid := server.validAccount(ctx, uri.ID, req.Currency)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !access.CanViewAccount(authPayload, account) {
+		abortWithError(ctx, errAccountNotOwned)
+		return
+	}
+
+	result, err := cashTx(ctx, db.CashTxParams{
+		AccountID: account.ID,
+		Amount:    req.Amount,
+	})
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, cashResponse{
+		Account: result.Account,
+		Entry:   result.Entry,
+	})
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// the kinds of listing a cursor can continue, a cursor of one listing is rejected by the others
+const (
+	cursorKindAccounts  = "accounts"
+	cursorKindEntries   = "entries"
+	cursorKindTransfers = "transfers"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// cursor is the keyset position of the last row of a page, the next page starts right after it
+type cursor struct {
+	Kind      string    `json:"kind"`
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// cursorCodec turns cursors into opaque tokens signed with HMAC-SHA256,
+// so clients can't forge a position they were never given
+type cursorCodec struct {
+	key []byte
+}
+
+// newCursorCodec creates a cursor codec signing with the key,
+// an empty key is replaced by a random one and the cursors don't outlive the process
+func newCursorCodec(key string) (*cursorCodec, error) {
+	if key != "" {
+		return &cursorCodec{key: []byte(key)}, nil
+	}
+
+	randomKey := make([]byte, 32)
+	if _, err := rand.Read(randomKey); err != nil {
+		return nil, err
+	}
+	return &cursorCodec{key: randomKey}, nil
+}
+
+// encode creates the token of the cursor pointing at the row
+func (codec *cursorCodec) encode(kind string, id int64, createdAt time.Time) (string, error) {
+	payload, err := json.Marshal(cursor{Kind: kind, ID: id, CreatedAt: createdAt})
+	if err != nil {
+		return "", err
+	}
+
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+	signature := base64.RawURLEncoding.EncodeToString(codec.sign(encodedPayload))
+	return encodedPayload + "." + signature, nil
+}
+
+// decode checks the signature and the kind of the token and returns its cursor
+func (codec *cursorCodec) decode(kind string, token string) (cursor, error) {
+	encodedPayload, encodedSignature, found := strings.Cut(token, ".")
+	if !found {
+		return cursor{}, errInvalidCursor
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, codec.sign(encodedPayload)) {
+		return cursor{}, errInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return cursor{}, errInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(payload, &c); err != nil || c.Kind != kind {
+		return cursor{}, errInvalidCursor
+	}
+	return c, nil
+}
+
+func (codec *cursorCodec) sign(encodedPayload string) []byte {
+	mac := hmac.New(sha256.New, codec.key)
+	mac.Write([]byte(encodedPayload))
+	return mac.Sum(nil)
+}
+
+// pageRequest is the pagination part of the list requests. The cursor is the next_cursor of the
+// previous response, page_id is the page based fallback, without either the first page is listed.
+type pageRequest struct {
+	Cursor   string `form:"cursor" binding:"omitempty,excluded_with=PageID"`
+	PageID   int32  `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// page is the window of a listing selected by a page request
+type page struct {
+	AfterCreatedAt pgtype.Timestamptz
+	AfterID        pgtype.Int8
+	Limit          int32
+	Offset         int32
+}
+
+// page decodes the cursor of the request or falls back to the page number
+func (codec *cursorCodec) page(kind string, req pageRequest) (page, error) {
+	p := page{Limit: req.PageSize}
+	if req.Cursor == "" {
+		if req.PageID > 1 {
+			p.Offset = req.PageSize * (req.PageID - 1)
+		}
+		return p, nil
+	}
+
+	c, err := codec.decode(kind, req.Cursor)
+	if err != nil {
+		return page{}, err
+	}
+	p.AfterCreatedAt = pgtype.Timestamptz{Time: c.CreatedAt, Valid: true}
+	p.AfterID = pgtype.Int8{Int64: c.ID, Valid: true}
+	return p, nil
+}
+
+// nextCursor returns the cursor of the page after the one listed, a page that isn't full is the last one
+// and gets no cursor
+func (codec *cursorCodec) nextCursor(kind string, req pageRequest, count int, lastID int64, lastCreatedAt time.Time) (string, error) {
+	if count < int(req.PageSize) {
+		return "", nil
+	}
+	return codec.encode(kind, lastID, lastCreatedAt)
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuuban007/simplebank/util"
+)
+
+func TestCursorCodec(t *testing.T) {
+	codec, err := newCursorCodec(util.RandomString(32))
+	require.NoError(t, err)
+
+	createdAt := time.Now().UTC()
+	token, err := codec.encode(cursorKindTransfers, 42, createdAt)
+	require.NoError(t, err)
+
+	c, err := codec.decode(cursorKindTransfers, token)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), c.ID)
+	require.True(t, createdAt.Equal(c.CreatedAt))
+
+	_, err = codec.decode(cursorKindAccounts, token)
+	require.ErrorIs(t, err, errInvalidCursor)
+
+	otherCodec, err := newCursorCodec("")
+	require.NoError(t, err)
+	_, err = otherCodec.decode(cursorKindTransfers, token)
+	require.ErrorIs(t, err, errInvalidCursor)
+
+	for _, invalid := range []string{"", "payload", token[1:], token + "=", token[:len(token)-1]} {
+		_, err = codec.decode(cursorKindTransfers, invalid)
+		require.ErrorIs(t, err, errInvalidCursor)
+	}
+}
+
+func TestCursorCodecPage(t *testing.T) {
+	codec, err := newCursorCodec(util.RandomString(32))
+	require.NoError(t, err)
+
+	p, err := codec.page(cursorKindEntries, pageRequest{PageID: 3, PageSize: 5})
+	require.NoError(t, err)
+	require.Equal(t, page{Limit: 5, Offset: 10}, p)
+
+	p, err = codec.page(cursorKindEntries, pageRequest{PageSize: 5})
+	require.NoError(t, err)
+	require.Equal(t, page{Limit: 5}, p)
+
+	createdAt := time.Now().UTC().Truncate(time.Microsecond)
+	next, err := codec.nextCursor(cursorKindEntries, pageRequest{PageSize: 5}, 5, 7, createdAt)
+	require.NoError(t, err)
+	require.NotEmpty(t, next)
+
+	p, err = codec.page(cursorKindEntries, pageRequest{Cursor: next, PageSize: 5})
+	require.NoError(t, err)
+	require.True(t, p.AfterCreatedAt.Valid)
+	require.True(t, createdAt.Equal(p.AfterCreatedAt.Time))
+	require.Equal(t, int64(7), p.AfterID.Int64)
+	require.Zero(t, p.Offset)
+
+	// a page that isn't full is the last one
+	next, err = codec.nextCursor(cursorKindEntries, pageRequest{PageSize: 5}, 4, 7, createdAt)
+	require.NoError(t, err)
+	require.Empty(t, next)
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yuuban007/simplebank/access"
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/token"
+)
+
+type listEntriesURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type listEntriesRequest struct {
+	pageRequest
+}
+
+type listEntriesResponse struct {
+	Entries    []db.Entry `json:"entries"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// listEntries lists the entries of an account of the authenticated user, bank staff can list any account
+func (server *Server) listEntries(ctx *gin.Context) {
+	var uri listEntriesURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+	var req listEntriesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+	page, err := server.cursors.page(cursorKindEntries, req.pageRequest)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		abortWithError(ctx, notFoundError(err, codeAccountNotFound, "account [%d] not found", uri.ID))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !access.CanViewAccount(authPayload, account) {
+		abortWithError(ctx, errAccountNotOwned)
+		return
+	}
+
+	arg := db.ListEntryParams{
+		AccountID:      account.ID,
+		AfterCreatedAt: page.AfterCreatedAt,
+		AfterID:        page.AfterID,
+		Limit:          page.Limit,
+		Offset:         page.Offset,
+	}
+	entries, err := server.store.ListEntry(ctx, arg)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	rsp := listEntriesResponse{Entries: entries}
+	if n := len(entries); n > 0 {
+		last := entries[n-1]
+		rsp.NextCursor, err = server.cursors.nextCursor(cursorKindEntries, req.pageRequest, n, last.ID, last.CreatedAt)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
@@ -0,0 +1,293 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/yuuban007/simplebank/access"
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/fx"
+	"github.com/yuuban007/simplebank/token"
+)
+
+// Stable error codes of the API, clients branch on these rather than on the messages
+const (
+	codeInvalidRequest          = "INVALID_REQUEST"
+	codeValidationFailed        = "VALIDATION_FAILED"
+	codeInvalidCursor           = "INVALID_CURSOR"
+	codeCurrencyMismatch        = "CURRENCY_MISMATCH"
+	codeAmountTooSmall          = "AMOUNT_TOO_SMALL"
+	codeAmountTooLarge          = "AMOUNT_TOO_LARGE"
+	codeInvalidPeriod           = "INVALID_PERIOD"
+	codeMissingAuthorization    = "MISSING_AUTHORIZATION"
+	codeInvalidAuthorization    = "INVALID_AUTHORIZATION"
+	codeInvalidToken            = "INVALID_TOKEN"
+	codeTokenExpired            = "TOKEN_EXPIRED"
+	codeTokenRevoked            = "TOKEN_REVOKED"
+	codeInvalidSession          = "INVALID_SESSION"
+	codeInvalidCredentials      = "INVALID_CREDENTIALS"
+	codePermissionDenied        = "PERMISSION_DENIED"
+	codeAccountNotOwned         = "ACCOUNT_NOT_OWNED"
+	codeTransferNotOwned        = "TRANSFER_NOT_OWNED"
+	codeNotFound                = "NOT_FOUND"
+	codeRouteNotFound           = "ROUTE_NOT_FOUND"
+	codeSessionNotFound         = "SESSION_NOT_FOUND"
+	codeAccountNotFound         = "ACCOUNT_NOT_FOUND"
+	codeTransferNotFound        = "TRANSFER_NOT_FOUND"
+	codePublicKeysNotFound      = "PUBLIC_KEYS_NOT_FOUND"
+	codeAlreadyExists           = "ALREADY_EXISTS"
+	codeUserAlreadyExists       = "USER_ALREADY_EXISTS"
+	codeAccountAlreadyExists    = "ACCOUNT_ALREADY_EXISTS"
+	codeInvalidReference        = "INVALID_REFERENCE"
+	codeInvalidStatusTransition = "INVALID_STATUS_TRANSITION"
+	codeInsufficientFunds       = "INSUFFICIENT_FUNDS"
+	codeAccountFrozen           = "ACCOUNT_FROZEN"
+	codeAccountClosed           = "ACCOUNT_CLOSED"
+	codeAccountBalanceNotZero   = "ACCOUNT_BALANCE_NOT_ZERO"
+	codeInvalidSweepAccount     = "INVALID_SWEEP_ACCOUNT"
+	codeSettlementAccount       = "SETTLEMENT_ACCOUNT"
+	codeExchangeRateNotFound    = "EXCHANGE_RATE_NOT_FOUND"
+	codeExchangeRateRequired    = "EXCHANGE_RATE_REQUIRED"
+	codeIdempotencyKeyReused    = "IDEMPOTENCY_KEY_REUSED"
+	codeRateLimited             = "RATE_LIMITED"
+	codeLoginLocked             = "LOGIN_LOCKED"
+	codeInternal                = "INTERNAL"
+)
+
+// apiError is an error with the HTTP status and the stable code of its response,
+// the wrapped error is only logged and never sent to the client
+type apiError struct {
+	Status  int
+	Code    string
+	Message string
+	Details []fieldError
+	Err     error
+}
+
+func (err *apiError) Error() string {
+	if err.Err != nil {
+		return fmt.Sprintf("%s: %v", err.Message, err.Err)
+	}
+	return err.Message
+}
+
+func (err *apiError) Unwrap() error {
+	return err.Err
+}
+
+// fieldError tells which field of the request failed which validation rule
+type fieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+type errorBody struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Details   []fieldError `json:"details,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+var (
+	errAccountNotOwned = &apiError{
+		Status:  http.StatusUnauthorized,
+		Code:    codeAccountNotOwned,
+		Message: "account doesn't belong to the authenticated user",
+	}
+	errTransferNotOwned = &apiError{
+		Status:  http.StatusUnauthorized,
+		Code:    codeTransferNotOwned,
+		Message: "transfer doesn't touch any account of the authenticated user",
+	}
+)
+
+// knownErrors maps the errors of the store and the other packages to their status and code,
+// so that every handler answers the same way to the same error
+var knownErrors = []struct {
+	target error
+	status int
+	code   string
+}{
+	{errInvalidCursor, http.StatusBadRequest, codeInvalidCursor},
+	{access.ErrInvalidCredentials, http.StatusUnauthorized, codeInvalidCredentials},
+	{token.ErrExpiredToken, http.StatusUnauthorized, codeTokenExpired},
+	{token.ErrInvalidToken, http.StatusUnauthorized, codeInvalidToken},
+	{db.ErrInvalidStatusTransition, http.StatusConflict, codeInvalidStatusTransition},
+	{db.ErrInsufficientFunds, http.StatusUnprocessableEntity, codeInsufficientFunds},
+	{db.ErrAccountFrozen, http.StatusUnprocessableEntity, codeAccountFrozen},
+	{db.ErrAccountClosed, http.StatusUnprocessableEntity, codeAccountClosed},
+	{db.ErrAccountBalanceNotZero, http.StatusUnprocessableEntity, codeAccountBalanceNotZero},
+	{db.ErrInvalidSweepAccount, http.StatusUnprocessableEntity, codeInvalidSweepAccount},
+	{db.ErrSettlementAccount, http.StatusUnprocessableEntity, codeSettlementAccount},
+	{db.ErrExchangeRateRequired, http.StatusUnprocessableEntity, codeExchangeRateRequired},
+	{db.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity, codeIdempotencyKeyReused},
+	{fx.ErrRateNotFound, http.StatusUnprocessableEntity, codeExchangeRateNotFound},
+	{fx.ErrAmountOverflow, http.StatusBadRequest, codeAmountTooLarge},
+}
+
+// toAPIError finds the status and code of the error, unknown errors are internal errors
+// whose message isn't shown to the client
+func toAPIError(err error) *apiError {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	for _, known := range knownErrors {
+		if errors.Is(err, known.target) {
+			return &apiError{Status: known.status, Code: known.code, Message: err.Error(), Err: err}
+		}
+	}
+
+	if errors.Is(err, db.ErrRecordNotFound) {
+		return &apiError{Status: http.StatusNotFound, Code: codeNotFound, Message: "resource not found", Err: err}
+	}
+
+	switch db.ErrorCode(err) {
+	case db.UniqueViolation:
+		return &apiError{Status: http.StatusConflict, Code: codeAlreadyExists, Message: "resource already exists", Err: err}
+	case db.ForeignKeyViolation:
+		return &apiError{Status: http.StatusUnprocessableEntity, Code: codeInvalidReference, Message: "referenced resource doesn't exist", Err: err}
+	}
+
+	return &apiError{Status: http.StatusInternalServerError, Code: codeInternal, Message: "internal server error", Err: err}
+}
+
+// abortWithError writes the error response of the error and stops the handler chain.
+// The error is attached to the context so the logger records the cause.
+func abortWithError(ctx *gin.Context, err error) {
+	apiErr := toAPIError(err)
+	ctx.Error(err)
+	ctx.AbortWithStatusJSON(apiErr.Status, errorResponse{
+		Error: errorBody{
+			Code:      apiErr.Code,
+			Message:   apiErr.Message,
+			Details:   apiErr.Details,
+			RequestID: ctx.GetString(requestIDKey),
+		},
+	})
+}
+
+// newAPIError creates an error answered with the status and code
+func newAPIError(status int, code string, format string, args ...any) *apiError {
+	return &apiError{Status: status, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// notFoundError gives a missing row the code of the resource, other errors are returned as they are
+func notFoundError(err error, code string, format string, args ...any) error {
+	if errors.Is(err, db.ErrRecordNotFound) {
+		return &apiError{Status: http.StatusNotFound, Code: code, Message: fmt.Sprintf(format, args...), Err: err}
+	}
+	return err
+}
+
+// alreadyExistsError gives a unique violation the code of the resource, other errors are returned as they are
+func alreadyExistsError(err error, code string, message string) error {
+	if db.ErrorCode(err) == db.UniqueViolation {
+		return &apiError{Status: http.StatusConflict, Code: code, Message: message, Err: err}
+	}
+	return err
+}
+
+// tokenError answers a token that fails verification as unauthenticated, telling expired tokens apart
+func tokenError(err error) error {
+	if errors.Is(err, token.ErrExpiredToken) {
+		return err
+	}
+	return &apiError{Status: http.StatusUnauthorized, Code: codeInvalidToken, Message: "invalid token", Err: err}
+}
+
+// bindingError turns an error of binding the request into a bad request,
+// with the failed validation rules of each field when there are some
+func bindingError(err error) error {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		details := make([]fieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			details = append(details, fieldError{
+				Field:   fe.Field(),
+				Rule:    fe.Tag(),
+				Param:   fe.Param(),
+				Message: validationMessage(fe),
+			})
+		}
+		return &apiError{
+			Status:  http.StatusBadRequest,
+			Code:    codeValidationFailed,
+			Message: "request validation failed",
+			Details: details,
+			Err:     err,
+		}
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return &apiError{
+			Status:  http.StatusBadRequest,
+			Code:    codeValidationFailed,
+			Message: "request validation failed",
+			Details: []fieldError{{
+				Field:   typeErr.Field,
+				Rule:    "type",
+				Param:   typeErr.Type.Kind().String(),
+				Message: fmt.Sprintf("must be a %s", typeErr.Type.Kind()),
+			}},
+			Err: err,
+		}
+	}
+
+	return &apiError{Status: http.StatusBadRequest, Code: codeInvalidRequest, Message: "malformed request", Err: err}
+}
+
+// validationMessage describes the validation rule a field failed
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "gtefield":
+		return fmt.Sprintf("must not be less than %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of %s", strings.ReplaceAll(fe.Param(), " ", ", "))
+	case "excluded_with":
+		return fmt.Sprintf("can't be combined with %s", fe.Param())
+	case "alphanum":
+		return "must only contain letters and digits"
+	case "email":
+		return "must be an email address"
+	case "currency":
+		return "must be a supported currency"
+	}
+	return fmt.Sprintf("failed the %s rule", fe.Tag())
+}
+
+// requestFieldName names the fields in validation errors after the json, form or uri key of the request
+func requestFieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form", "uri"} {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
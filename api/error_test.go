@@ -0,0 +1,238 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/yuuban007/simplebank/db/mock"
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/fx"
+	"github.com/yuuban007/simplebank/token"
+	"github.com/yuuban007/simplebank/util"
+	"go.uber.org/mock/gomock"
+)
+
+func TestToAPIError(t *testing.T) {
+	testCases := []struct {
+		name           string
+		err            error
+		status         int
+		code           string
+		messageVisible bool
+	}{
+		{"APIError", errAccountNotOwned, http.StatusUnauthorized, codeAccountNotOwned, true},
+		{"WrappedAPIError", fmt.Errorf("wrapped: %w", errAccountNotOwned), http.StatusUnauthorized, codeAccountNotOwned, true},
+		{"InsufficientFunds", fmt.Errorf("%w: account [1]", db.ErrInsufficientFunds), http.StatusUnprocessableEntity, codeInsufficientFunds, true},
+		{"AccountFrozen", &db.AccountStatusError{AccountID: 1, Status: util.AccountStatusFrozen}, http.StatusUnprocessableEntity, codeAccountFrozen, true},
+		{"AccountClosed", &db.AccountStatusError{AccountID: 1, Status: util.AccountStatusClosed}, http.StatusUnprocessableEntity, codeAccountClosed, true},
+		{"InvalidStatusTransition", db.ErrInvalidStatusTransition, http.StatusConflict, codeInvalidStatusTransition, true},
+		{"BalanceNotZero", db.ErrAccountBalanceNotZero, http.StatusUnprocessableEntity, codeAccountBalanceNotZero, true},
+		{"InvalidSweepAccount", db.ErrInvalidSweepAccount, http.StatusUnprocessableEntity, codeInvalidSweepAccount, true},
+		{"SettlementAccount", db.ErrSettlementAccount, http.StatusUnprocessableEntity, codeSettlementAccount, true},
+		{"IdempotencyKeyReused", db.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity, codeIdempotencyKeyReused, true},
+		{"RateNotFound", fx.ErrRateNotFound, http.StatusUnprocessableEntity, codeExchangeRateNotFound, true},
+		{"ExpiredToken", token.ErrExpiredToken, http.StatusUnauthorized, codeTokenExpired, true},
+		{"InvalidCursor", errInvalidCursor, http.StatusBadRequest, codeInvalidCursor, true},
+		{"NoRows", db.ErrRecordNotFound, http.StatusNotFound, codeNotFound, false},
+		{"UniqueViolation", &pgconn.PgError{Code: db.UniqueViolation, Message: "duplicate key value violates unique constraint"}, http.StatusConflict, codeAlreadyExists, false},
+		{"ForeignKeyViolation", &pgconn.PgError{Code: db.ForeignKeyViolation, Message: "violates foreign key constraint"}, http.StatusUnprocessableEntity, codeInvalidReference, false},
+		{"Unknown", pgx.ErrTxClosed, http.StatusInternalServerError, codeInternal, false},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			apiErr := toAPIError(tc.err)
+			require.Equal(t, tc.status, apiErr.Status)
+			require.Equal(t, tc.code, apiErr.Code)
+			if tc.messageVisible {
+				require.Contains(t, tc.err.Error(), apiErr.Message)
+			} else {
+				// the raw database message never reaches the client
+				require.NotContains(t, apiErr.Message, tc.err.Error())
+			}
+		})
+	}
+}
+
+func TestNotFoundError(t *testing.T) {
+	err := notFoundError(db.ErrRecordNotFound, codeAccountNotFound, "account [%d] not found", 1)
+	apiErr := toAPIError(err)
+	require.Equal(t, http.StatusNotFound, apiErr.Status)
+	require.Equal(t, codeAccountNotFound, apiErr.Code)
+	require.Equal(t, "account [1] not found", apiErr.Message)
+	require.ErrorIs(t, err, db.ErrRecordNotFound)
+
+	require.Equal(t, pgx.ErrTxClosed, notFoundError(pgx.ErrTxClosed, codeAccountNotFound, "account not found"))
+}
+
+func TestValidationErrorResponse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	stubTokenNotRevoked(store)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(store, t)
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{
+		"from_account_id": 1,
+		"to_account_id":   0,
+		"amount":          -10,
+		"currency":        "XYZ",
+	})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+	require.NoError(t, err)
+	request.Header.Set(requestIDHeader, "client-request-id")
+	addAuthorization(t, request, server.TokenMaker, authorizationTypeBearer, "user", util.DepositorRole, time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	rsp := requireErrorCode(t, recorder.Body, codeValidationFailed)
+	require.Equal(t, "client-request-id", rsp.Error.RequestID)
+	require.ElementsMatch(t, []fieldError{
+		{Field: "to_account_id", Rule: "required", Message: "is required"},
+		{Field: "amount", Rule: "gt", Param: "0", Message: "must be greater than 0"},
+		{Field: "currency", Rule: "currency", Message: "must be a supported currency"},
+	}, rsp.Error.Details)
+}
+
+func TestMalformedRequestResponse(t *testing.T) {
+	testCases := []struct {
+		name    string
+		body    string
+		code    string
+		details []fieldError
+	}{
+		{
+			name: "Syntax",
+			body: `{"username":`,
+			code: codeInvalidRequest,
+		},
+		{
+			name: "FieldType",
+			body: `{"username": 1, "password": "secret12"}`,
+			code: codeValidationFailed,
+			details: []fieldError{
+				{Field: "username", Rule: "type", Param: "string", Message: "must be a string"},
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+
+			server := newTestServer(store, t)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+			rsp := requireErrorCode(t, recorder.Body, tc.code)
+			require.Equal(t, tc.details, rsp.Error.Details)
+		})
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	testCases := []struct {
+		name          string
+		requestID     string
+		checkResponse func(t *testing.T, requestID string, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "KeepsClientID",
+			requestID: "3f6c1e2a-client",
+			checkResponse: func(t *testing.T, requestID string, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, requestID, recorder.Header().Get(requestIDHeader))
+			},
+		},
+		{
+			name: "GeneratesID",
+			checkResponse: func(t *testing.T, requestID string, recorder *httptest.ResponseRecorder) {
+				require.NotEmpty(t, recorder.Header().Get(requestIDHeader))
+			},
+		},
+		{
+			name:      "ReplacesInvalidID",
+			requestID: "bad id\twith spaces",
+			checkResponse: func(t *testing.T, requestID string, recorder *httptest.ResponseRecorder) {
+				generated := recorder.Header().Get(requestIDHeader)
+				require.NotEmpty(t, generated)
+				require.NotEqual(t, requestID, generated)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			server := newTestServer(mockdb.NewMockStore(ctrl), t)
+			recorder := httptest.NewRecorder()
+
+			// an unauthenticated request is answered with an error carrying the request id
+			request, err := http.NewRequest(http.MethodGet, "/accounts/1", nil)
+			require.NoError(t, err)
+			if tc.requestID != "" {
+				request.Header.Set(requestIDHeader, tc.requestID)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+			rsp := requireErrorCode(t, recorder.Body, codeMissingAuthorization)
+			require.Equal(t, recorder.Header().Get(requestIDHeader), rsp.Error.RequestID)
+			tc.checkResponse(t, tc.requestID, recorder)
+		})
+	}
+}
+
+func TestNoRoute(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	server := newTestServer(mockdb.NewMockStore(ctrl), t)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/missing", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+	requireErrorCode(t, recorder.Body, codeRouteNotFound)
+}
+
+// requireErrorCode checks the body is an error response with the code and returns it
+func requireErrorCode(t *testing.T, body *bytes.Buffer, code string) errorResponse {
+	data, err := io.ReadAll(bytes.NewReader(body.Bytes()))
+	require.NoError(t, err)
+
+	var rsp errorResponse
+	err = json.Unmarshal(data, &rsp)
+	require.NoError(t, err)
+	require.Equal(t, code, rsp.Error.Code)
+	require.NotEmpty(t, rsp.Error.Message)
+	return rsp
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yuuban007/simplebank/token"
+	"github.com/yuuban007/simplebank/util"
+)
+
+// readinessTimeout bounds the checks of a readiness probe, so a hanging database fails the probe
+const readinessTimeout = 2 * time.Second
+
+const (
+	healthStatusOK          = "ok"
+	healthStatusUnavailable = "unavailable"
+)
+
+type healthResponse struct {
+	Status string `json:"status"`
+}
+
+// getHealth tells the process is up and serving, it doesn't look at the dependencies
+// so that an outage of postgres doesn't get the server restarted
+func (server *Server) getHealth(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, healthResponse{Status: healthStatusOK})
+}
+
+type readinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// getReadiness tells whether the server can take traffic: postgres is reachable,
+// its schema is migrated to the version the server was built for, and tokens can be created and verified
+func (server *Server) getReadiness(ctx *gin.Context) {
+	checkCtx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	rsp := readinessResponse{
+		Status: healthStatusOK,
+		Checks: map[string]string{},
+	}
+	checks := []struct {
+		name  string
+		check func(ctx context.Context) error
+	}{
+		{"database", server.store.Ping},
+		{"migrations", server.checkMigrations},
+		{"token_maker", server.checkTokenMaker},
+	}
+	for _, c := range checks {
+		if err := c.check(checkCtx); err != nil {
+			// the cause is only logged, the probe is unauthenticated
+			ctx.Error(fmt.Errorf("readiness check %s: %w", c.name, err))
+			rsp.Status = healthStatusUnavailable
+			rsp.Checks[c.name] = healthStatusUnavailable
+			continue
+		}
+		rsp.Checks[c.name] = healthStatusOK
+	}
+
+	if rsp.Status != healthStatusOK {
+		ctx.JSON(http.StatusServiceUnavailable, rsp)
+		return
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// checkMigrations makes sure the schema has all the migrations the server was built with and none failed halfway
+func (server *Server) checkMigrations(ctx context.Context) error {
+	version, dirty, err := server.store.MigrationVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("migration %d is dirty", version)
+	}
+	if version < server.migrationVersion {
+		return fmt.Errorf("schema version %d is behind %d", version, server.migrationVersion)
+	}
+	return nil
+}
+
+// checkTokenMaker makes sure the signing keys are usable by creating and verifying a short lived token
+func (server *Server) checkTokenMaker(ctx context.Context) error {
+	accessToken, _, err := server.TokenMaker.CreateToken("readiness", util.DepositorRole, token.AccessToken, time.Minute)
+	if err != nil {
+		return err
+	}
+	_, err = server.TokenMaker.VerifyToken(accessToken, token.AccessToken)
+	return err
+}
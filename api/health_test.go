@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"github.com/yuuban007/simplebank/db/migration"
+	mockdb "github.com/yuuban007/simplebank/db/mock"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetHealthAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	// liveness never touches the database
+	store.EXPECT().Ping(gomock.Any()).Times(0)
+
+	server := newTestServer(store, t)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/healthz", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp healthResponse
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&rsp))
+	require.Equal(t, healthStatusOK, rsp.Status)
+}
+
+func TestGetReadinessAPI(t *testing.T) {
+	latest, err := migration.LatestVersion()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().MigrationVersion(gomock.Any()).Times(1).Return(latest, false, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireReadiness(t, recorder, healthStatusOK, map[string]string{
+					"database":    healthStatusOK,
+					"migrations":  healthStatusOK,
+					"token_maker": healthStatusOK,
+				})
+			},
+		},
+		{
+			name: "DatabaseUnreachable",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(pgx.ErrTxClosed)
+				store.EXPECT().MigrationVersion(gomock.Any()).Times(1).Return(int64(0), false, pgx.ErrTxClosed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				requireReadiness(t, recorder, healthStatusUnavailable, map[string]string{
+					"database":    healthStatusUnavailable,
+					"migrations":  healthStatusUnavailable,
+					"token_maker": healthStatusOK,
+				})
+			},
+		},
+		{
+			name: "MigrationsPending",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().MigrationVersion(gomock.Any()).Times(1).Return(latest-1, false, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				requireReadiness(t, recorder, healthStatusUnavailable, map[string]string{
+					"database":    healthStatusOK,
+					"migrations":  healthStatusUnavailable,
+					"token_maker": healthStatusOK,
+				})
+			},
+		},
+		{
+			name: "MigrationDirty",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().MigrationVersion(gomock.Any()).Times(1).Return(latest, true, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				requireReadiness(t, recorder, healthStatusUnavailable, map[string]string{
+					"database":    healthStatusOK,
+					"migrations":  healthStatusUnavailable,
+					"token_maker": healthStatusOK,
+				})
+			},
+		},
+		{
+			name: "SchemaAhead",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().MigrationVersion(gomock.Any()).Times(1).Return(latest+1, false, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(store, t)
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/readyz", nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireReadiness(t *testing.T, recorder *httptest.ResponseRecorder, status string, checks map[string]string) {
+	var rsp readinessResponse
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&rsp))
+	require.Equal(t, status, rsp.Status)
+	require.Equal(t, checks, rsp.Checks)
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yuuban007/simplebank/logging"
+	"github.com/yuuban007/simplebank/token"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxLoggedBodySize bounds the request bodies logged at debug level, a longer body is redacted as a whole
+const maxLoggedBodySize = 4096
+
+// loggerMiddleware writes one structured record per request once it is handled.
+// At debug level it also logs the JSON request body, with its passwords and tokens redacted.
+func loggerMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		var body []byte
+		if logger.Enabled(ctx, slog.LevelDebug) {
+			body = peekJSONBody(ctx.Request)
+		}
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("request_id", ctx.GetString(requestIDKey)),
+			slog.String("method", ctx.Request.Method),
+			slog.String("route", ctx.FullPath()),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", ctx.ClientIP()),
+		}
+		if payload, ok := ctx.Get(authorizationPayloadKey); ok {
+			attrs = append(attrs, slog.String("username", payload.(*token.Payload).Username))
+		}
+		if spanContext := trace.SpanContextFromContext(ctx.Request.Context()); spanContext.IsValid() {
+			attrs = append(attrs, slog.String("trace_id", spanContext.TraceID().String()))
+		}
+		if len(ctx.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", strings.Join(ctx.Errors.Errors(), "; ")))
+		}
+		if body != nil {
+			attrs = append(attrs, slog.String("body", logging.RedactJSON(body)))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		logger.LogAttrs(ctx, level, "request", attrs...)
+	}
+}
+
+// peekJSONBody reads the beginning of a JSON request body and puts it back for the handler
+func peekJSONBody(request *http.Request) []byte {
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if mediaType != gin.MIMEJSON {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(request.Body, maxLoggedBodySize+1))
+	request.Body = readCloser{
+		Reader: io.MultiReader(bytes.NewReader(body), request.Body),
+		Closer: request.Body,
+	}
+	return body
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// recoveryMiddleware answers a panicking handler with an internal error and logs the panic with its stack
+func recoveryMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(ctx *gin.Context, recovered any) {
+		logger.ErrorContext(ctx, "panic recovered",
+			slog.String("request_id", ctx.GetString(requestIDKey)),
+			slog.Any("panic", recovered),
+			slog.String("stack", string(debug.Stack())),
+		)
+		abortWithError(ctx, fmt.Errorf("panic: %v", recovered))
+	})
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/yuuban007/simplebank/logging"
+	"github.com/yuuban007/simplebank/token"
+)
+
+// newLoggedRouter creates a router logging to the buffer in JSON, like the server does
+func newLoggedRouter(t *testing.T, level slog.Level) (*gin.Engine, *bytes.Buffer) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: level}))
+
+	router := gin.New()
+	router.Use(requestIDMiddleware(), loggerMiddleware(logger), recoveryMiddleware(logger))
+	router.POST("/users/:username/login", func(ctx *gin.Context) {
+		// the handler still gets the whole body after it was logged
+		body, err := io.ReadAll(ctx.Request.Body)
+		require.NoError(t, err)
+		ctx.Set(authorizationPayloadKey, &token.Payload{Username: ctx.Param("username")})
+		ctx.Data(http.StatusOK, gin.MIMEJSON, body)
+	})
+	router.GET("/panic", func(ctx *gin.Context) {
+		panic("boom")
+	})
+	return router, &logs
+}
+
+func decodeLogRecords(t *testing.T, logs *bytes.Buffer) []map[string]any {
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLoggerMiddleware(t *testing.T) {
+	router, logs := newLoggedRouter(t, slog.LevelInfo)
+
+	body := `{"username":"alice","password":"secret123"}`
+	request, err := http.NewRequest(http.MethodPost, "/users/alice/login", strings.NewReader(body))
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", gin.MIMEJSON)
+	request.Header.Set(requestIDHeader, "request-1")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, body, recorder.Body.String())
+
+	records := decodeLogRecords(t, logs)
+	require.Len(t, records, 1)
+	record := records[0]
+	require.Equal(t, "INFO", record["level"])
+	require.Equal(t, "request", record["msg"])
+	require.Equal(t, "request-1", record["request_id"])
+	require.Equal(t, http.MethodPost, record["method"])
+	require.Equal(t, "/users/:username/login", record["route"])
+	require.Equal(t, float64(http.StatusOK), record["status"])
+	require.Equal(t, "alice", record["username"])
+	require.Contains(t, record, "latency")
+	// bodies are only logged at debug level
+	require.NotContains(t, record, "body")
+}
+
+func TestLoggerMiddlewareRedactsBody(t *testing.T) {
+	router, logs := newLoggedRouter(t, slog.LevelDebug)
+
+	body := `{"username":"alice","password":"secret123","refresh_token":"v2.local.abc"}`
+	request, err := http.NewRequest(http.MethodPost, "/users/alice/login", strings.NewReader(body))
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", gin.MIMEJSON)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	require.Equal(t, body, recorder.Body.String())
+
+	records := decodeLogRecords(t, logs)
+	require.Len(t, records, 1)
+	logged := records[0]["body"].(string)
+	require.Contains(t, logged, `"username":"alice"`)
+	require.Contains(t, logged, `"password":"`+logging.Redacted+`"`)
+	require.Contains(t, logged, `"refresh_token":"`+logging.Redacted+`"`)
+	require.NotContains(t, logs.String(), "secret123")
+	require.NotContains(t, logs.String(), "v2.local.abc")
+}
+
+func TestLoggerMiddlewareErrorLevels(t *testing.T) {
+	router, logs := newLoggedRouter(t, slog.LevelInfo)
+
+	request, err := http.NewRequest(http.MethodGet, "/panic", nil)
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+	requireErrorCode(t, recorder.Body, codeInternal)
+
+	request, err = http.NewRequest(http.MethodGet, "/missing", nil)
+	require.NoError(t, err)
+	router.ServeHTTP(httptest.NewRecorder(), request)
+
+	records := decodeLogRecords(t, logs)
+	require.Len(t, records, 3)
+
+	require.Equal(t, "ERROR", records[0]["level"])
+	require.Equal(t, "panic recovered", records[0]["msg"])
+	require.Equal(t, "boom", records[0]["panic"])
+	require.NotEmpty(t, records[0]["stack"])
+
+	require.Equal(t, "ERROR", records[1]["level"])
+	require.Equal(t, float64(http.StatusInternalServerError), records[1]["status"])
+	require.Equal(t, "panic: boom", records[1]["error"])
+
+	require.Equal(t, "WARN", records[2]["level"])
+	require.Equal(t, float64(http.StatusNotFound), records[2]["status"])
+}
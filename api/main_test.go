@@ -1,6 +1,8 @@
 package api
 
 import (
+	"io"
+	"log/slog"
 	"os"
 	"testing"
 	"time"
@@ -8,21 +10,57 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
 	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/ratelimit"
 	"github.com/yuuban007/simplebank/util"
 )
 
-func newTestServer(store db.Store, t *testing.T) *Server {
-	config := util.Config{
-		TokenSymmetricKey:   util.RandomString(32),
-		AccessTokenDuration: time.Minute,
+// testCursorSecretKey signs the cursors of every test server, so tests can create cursors up front
+var testCursorSecretKey = util.RandomString(32)
+
+// testLoginMaxFailures is the number of failed logins that lock a username out of a test server
+const testLoginMaxFailures = 3
+
+// newTestConfig is the configuration of the test servers, requests aren't rate limited
+func newTestConfig() util.Config {
+	return util.Config{
+		TokenSymmetricKey:    util.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+		IdempotencyKeyTTL:    time.Hour,
+		CursorSecretKey:      testCursorSecretKey,
+		LoginMaxFailures:     testLoginMaxFailures,
+		LoginLockoutDuration: time.Minute,
 	}
-	server, err := NewServer(config, store)
+}
+
+func newTestServer(store db.Store, t *testing.T) *Server {
+	return newTestServerWithConfig(t, newTestConfig(), store)
+}
+
+// newTestServerWithConfig creates a test server with its own limits
+func newTestServerWithConfig(t *testing.T, config util.Config, store db.Store) *Server {
+	limits, err := ratelimit.NewLimits(config, store)
+	require.NoError(t, err)
+
+	server, err := NewServer(config, store, limits)
 	require.NoError(t, err)
 
 	return server
 }
 
+// testCursor creates the cursor a test server would return for the row
+func testCursor(t *testing.T, kind string, id int64, createdAt time.Time) string {
+	cursors, err := newCursorCodec(testCursorSecretKey)
+	require.NoError(t, err)
+
+	cursor, err := cursors.encode(kind, id, createdAt)
+	require.NoError(t, err)
+	return cursor
+}
+
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode)
+	// keep the request logs out of the test output
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
 	os.Exit(m.Run())
 }
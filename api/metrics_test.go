@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/yuuban007/simplebank/db/mock"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	testCases := []struct {
+		name   string
+		url    string
+		route  string
+		status string
+	}{
+		{
+			name:   "MatchedRoute",
+			url:    "/healthz",
+			route:  "/healthz",
+			status: "200",
+		},
+		{
+			name:   "RouteTemplate",
+			url:    "/accounts/42",
+			route:  "/accounts/:id",
+			status: "401",
+		},
+		{
+			name:   "UnmatchedRoute",
+			url:    "/no/such/route",
+			route:  unmatchedRoute,
+			status: "404",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server := newTestServer(mockdb.NewMockStore(ctrl), t)
+			counter := httpRequestsTotal.WithLabelValues(http.MethodGet, tc.route, tc.status)
+			before := testutil.ToFloat64(counter)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+			server.router.ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.status, strconv.Itoa(recorder.Code))
+			require.Equal(t, before+1, testutil.ToFloat64(counter))
+		})
+	}
+}
+
+func TestGetMetricsAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(mockdb.NewMockStore(ctrl), t)
+
+	// a first request so that the request metrics have a series to expose
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/healthz", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+
+	// the metrics aren't served on the public API port
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	NewMetricsServer(newTestConfig()).Handler.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	body := recorder.Body.String()
+	require.Contains(t, body, `simplebank_http_requests_total{method="GET",route="/healthz",status="200"}`)
+	require.Contains(t, body, "simplebank_http_request_duration_seconds_bucket")
+}
@@ -1,51 +1,120 @@
 package api
 
 import (
-	"errors"
-	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/yuuban007/simplebank/db/sqlc"
 	"github.com/yuuban007/simplebank/token"
+	"github.com/yuuban007/simplebank/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 const (
 	authorizationHeaderKey  = "authorization"
 	authorizationTypeBearer = "bearer"
 	authorizationPayloadKey = "authorization_payload_key"
+
+	requestIDHeader    = "X-Request-ID"
+	requestIDKey       = "request_id"
+	maxRequestIDLength = 128
 )
 
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+// requestIDMiddleware gives every request an id, sent back in the X-Request-ID header and the error responses.
+// The id of the client is kept when it sends a reasonable one.
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := ctx.GetHeader(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		ctx.Set(requestIDKey, requestID)
+		ctx.Header(requestIDHeader, requestID)
+		ctx.Next()
+	}
+}
+
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range requestID {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// untracedRoutes are polled by the infrastructure, their spans would drown the ones of the clients
+var untracedRoutes = []string{"/healthz", "/readyz"}
+
+// tracingMiddleware starts a span for every request, continuing the W3C trace context of the incoming headers
+func tracingMiddleware() gin.HandlerFunc {
+	return otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(func(request *http.Request) bool {
+		return !slices.Contains(untracedRoutes, request.URL.Path)
+	}))
+}
+
+func authMiddleware(tokenMaker token.Maker, store db.Store) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
 		if len(authorizationHeader) == 0 {
-			err := errors.New("authorization header is not provided")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			abortWithError(ctx, newAPIError(http.StatusUnauthorized, codeMissingAuthorization, "authorization header is not provided"))
 			return
 		}
 
 		fields := strings.Fields(authorizationHeader)
 		if len(fields) < 2 {
-			err := errors.New("invalid authorization head format")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			abortWithError(ctx, newAPIError(http.StatusUnauthorized, codeInvalidAuthorization, "invalid authorization header format"))
 			return
 		}
 
 		authorizationType := strings.ToLower(fields[0])
 		if authorizationType != authorizationTypeBearer {
-			err := fmt.Errorf("unsupported authorization type %s", authorizationType)
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			abortWithError(ctx, newAPIError(http.StatusUnauthorized, codeInvalidAuthorization, "unsupported authorization type %s", authorizationType))
 			return
 		}
 
 		accessToken := fields[1]
-		payload, err := tokenMaker.VerifyToken(accessToken)
+		payload, err := tokenMaker.VerifyToken(accessToken, token.AccessToken)
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			abortWithError(ctx, tokenError(err))
 			return
 		}
+
+		// check if the token has been revoked by a logout
+		revoked, err := store.IsTokenRevoked(ctx, db.IsTokenRevokedParams{
+			ID:       payload.ID,
+			Username: payload.Username,
+			IssuedAt: payload.IssuedAt,
+		})
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		if revoked {
+			abortWithError(ctx, newAPIError(http.StatusUnauthorized, codeTokenRevoked, "token has been revoked"))
+			return
+		}
+
 		ctx.Set(authorizationPayloadKey, payload)
 		ctx.Next()
 	}
 }
+
+// authorizeMiddleware only lets through the requests whose token carries one of the given roles,
+// it must be used after authMiddleware
+func authorizeMiddleware(accessibleRoles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		if !slices.Contains(accessibleRoles, authPayload.Role) {
+			abortWithError(ctx, newAPIError(http.StatusForbidden, codePermissionDenied, "role %s is not allowed to access this resource", authPayload.Role))
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// openAPISpec is the OpenAPI 3 document of every route of the router,
+// TestOpenAPISpecCoversRoutes keeps the two in sync
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// swaggerInitializer replaces the initializer of Swagger UI to load the document of this API
+const swaggerInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "/openapi.json",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    plugins: [SwaggerUIBundle.plugins.DownloadUrl],
+    layout: "StandaloneLayout"
+  });
+};
+`
+
+func (server *Server) getOpenAPI(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, gin.MIMEJSON, openAPISpec)
+}
+
+// getSwaggerUI serves the files of Swagger UI embedded in the binary, the UI itself is at /swagger/
+func (server *Server) getSwaggerUI(ctx *gin.Context) {
+	filepath := ctx.Param("filepath")
+	if filepath == "/swagger-initializer.js" {
+		ctx.Data(http.StatusOK, "application/javascript; charset=utf-8", []byte(swaggerInitializer))
+		return
+	}
+	ctx.FileFromFS(filepath, http.FS(swaggerFiles.FS))
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/yuuban007/simplebank/db/mock"
+	"github.com/yuuban007/simplebank/util"
+	"go.uber.org/mock/gomock"
+)
+
+type openAPIDocument struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Enum []string `json:"enum"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+// ginPathParam matches the :param and *param segments of gin paths
+var ginPathParam = regexp.MustCompile(`[:*]([^/]+)`)
+
+func loadOpenAPIDocument(t *testing.T) openAPIDocument {
+	var doc openAPIDocument
+	require.NoError(t, json.Unmarshal(openAPISpec, &doc))
+	return doc
+}
+
+func TestOpenAPISpecCoversRoutes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	server := newTestServer(mockdb.NewMockStore(ctrl), t)
+	doc := loadOpenAPIDocument(t)
+
+	documented := make(map[string]bool)
+	for path, operations := range doc.Paths {
+		for method := range operations {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	registered := make(map[string]bool)
+	for _, route := range server.router.Routes() {
+		operation := route.Method + " " + ginPathParam.ReplaceAllString(route.Path, "{$1}")
+		registered[operation] = true
+		require.Truef(t, documented[operation], "route %s is missing from openapi.json", operation)
+	}
+	for operation := range documented {
+		require.Truef(t, registered[operation], "openapi.json documents %s which isn't a route", operation)
+	}
+}
+
+func TestOpenAPISpecCurrencies(t *testing.T) {
+	doc := loadOpenAPIDocument(t)
+
+	currencies := doc.Components.Schemas["Currency"].Enum
+	require.NotEmpty(t, currencies)
+	for _, currency := range currencies {
+		require.True(t, util.IsSupportedCurrency(currency))
+	}
+	require.ElementsMatch(t, []string{util.USD, util.EUR, util.CAD}, currencies)
+}
+
+func TestOpenAPIDocs(t *testing.T) {
+	testCases := []struct {
+		name          string
+		url           string
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Spec",
+			url:  "/openapi.json",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Header().Get("Content-Type"), "application/json")
+				require.JSONEq(t, string(openAPISpec), recorder.Body.String())
+			},
+		},
+		{
+			name: "SwaggerUI",
+			url:  "/swagger/",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Header().Get("Content-Type"), "text/html")
+				require.Contains(t, recorder.Body.String(), "swagger-initializer.js")
+			},
+		},
+		{
+			name: "SwaggerInitializer",
+			url:  "/swagger/swagger-initializer.js",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `url: "/openapi.json"`)
+			},
+		},
+		{
+			name: "SwaggerAsset",
+			url:  "/swagger/swagger-ui-bundle.js",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "SwaggerFileNotFound",
+			url:  "/swagger/missing.js",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			server := newTestServer(mockdb.NewMockStore(ctrl), t)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yuuban007/simplebank/ratelimit"
+	"github.com/yuuban007/simplebank/token"
+)
+
+const (
+	rateLimitLimitHeader     = "X-RateLimit-Limit"
+	rateLimitRemainingHeader = "X-RateLimit-Remaining"
+	retryAfterHeader         = "Retry-After"
+)
+
+// rateLimitMiddleware counts the requests of each key and answers 429 once the key is over the limit.
+// When the backend fails the request is let through, the rate limit must not take the API down.
+func rateLimitMiddleware(limiter *ratelimit.Limiter, keyOf func(ctx *gin.Context) string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		result, err := limiter.Allow(ctx, keyOf(ctx))
+		if err != nil {
+			ctx.Error(err)
+			ctx.Next()
+			return
+		}
+		if result.Limit > 0 {
+			ctx.Header(rateLimitLimitHeader, strconv.FormatInt(result.Limit, 10))
+			ctx.Header(rateLimitRemainingHeader, strconv.FormatInt(result.Remaining, 10))
+		}
+		if !result.Allowed {
+			abortTooManyRequests(ctx, result.RetryAfter, codeRateLimited, "too many requests, retry later")
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// clientIPKey limits the requests per client IP
+func clientIPKey(ctx *gin.Context) string {
+	return ctx.ClientIP()
+}
+
+// usernameKey limits the requests per user, it must be used after authMiddleware
+func usernameKey(ctx *gin.Context) string {
+	return ctx.MustGet(authorizationPayloadKey).(*token.Payload).Username
+}
+
+// abortTooManyRequests answers 429 and tells the client in Retry-After how many seconds to wait
+func abortTooManyRequests(ctx *gin.Context, retryAfter time.Duration, code string, message string) {
+	seconds := max(int64(math.Ceil(retryAfter.Seconds())), 1)
+	ctx.Header(retryAfterHeader, strconv.FormatInt(seconds, 10))
+	abortWithError(ctx, newAPIError(http.StatusTooManyRequests, code, message))
+}
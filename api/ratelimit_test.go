@@ -0,0 +1,186 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/yuuban007/simplebank/db/mock"
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/ratelimit"
+	"github.com/yuuban007/simplebank/util"
+	"go.uber.org/mock/gomock"
+)
+
+func TestIPRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	// the rejected request doesn't reach the handler
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Any()).
+		Times(2).
+		Return(db.User{}, db.ErrRecordNotFound)
+
+	config := newTestConfig()
+	config.RateLimitIPRequests = 2
+	config.RateLimitWindow = time.Minute
+	server := newTestServerWithConfig(t, config, store)
+
+	login := func(remoteAddr string) *httptest.ResponseRecorder {
+		data, err := json.Marshal(gin.H{
+			"username": util.RandomOwner(),
+			"password": util.RandomString(8),
+		})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+		require.NoError(t, err)
+		request.RemoteAddr = remoteAddr
+		// the server trusts no proxy, a client can't pick its own IP
+		request.Header.Set("X-Forwarded-For", util.RandomString(6))
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := login("10.0.0.1:1234")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Equal(t, "2", recorder.Header().Get(rateLimitLimitHeader))
+	require.Equal(t, "1", recorder.Header().Get(rateLimitRemainingHeader))
+
+	recorder = login("10.0.0.1:5678")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Equal(t, "0", recorder.Header().Get(rateLimitRemainingHeader))
+
+	recorder = login("10.0.0.1:1234")
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	require.Equal(t, "60", recorder.Header().Get(retryAfterHeader))
+	requireErrorCode(t, recorder.Body, codeRateLimited)
+}
+
+func TestUserRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	stubTokenNotRevoked(store)
+
+	config := newTestConfig()
+	config.RateLimitUserRequests = 1
+	config.RateLimitWindow = time.Minute
+	server := newTestServerWithConfig(t, config, store)
+
+	get := func(username string, remoteAddr string) *httptest.ResponseRecorder {
+		request, err := http.NewRequest(http.MethodGet, "/accounts/0", nil)
+		require.NoError(t, err)
+		request.RemoteAddr = remoteAddr
+		addAuthorization(t, request, server.TokenMaker, authorizationTypeBearer, username, util.DepositorRole, time.Minute)
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// the invalid id is refused by the handler, after the limit is counted
+	user1 := util.RandomOwner()
+	require.Equal(t, http.StatusBadRequest, get(user1, "10.0.0.1:1234").Code)
+	require.Equal(t, http.StatusTooManyRequests, get(user1, "10.0.0.2:1234").Code)
+
+	// the other users have their own limit
+	require.Equal(t, http.StatusBadRequest, get(user1+"x", "10.0.0.3:1234").Code)
+}
+
+func TestAuthIPRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(0)
+
+	config := newTestConfig()
+	config.RateLimitUserRequests = 2
+	config.RateLimitWindow = time.Minute
+	server := newTestServerWithConfig(t, config, store)
+
+	get := func() *httptest.ResponseRecorder {
+		request, err := http.NewRequest(http.MethodGet, "/accounts/1", nil)
+		require.NoError(t, err)
+		request.RemoteAddr = "10.0.0.1:1234"
+		request.Header.Set(authorizationHeaderKey, authorizationTypeBearer+" invalid")
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// invalid tokens are counted per IP before they are checked
+	require.Equal(t, http.StatusUnauthorized, get().Code)
+	require.Equal(t, http.StatusUnauthorized, get().Code)
+	recorder := get()
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	requireErrorCode(t, recorder.Body, codeRateLimited)
+}
+
+func TestSharedRateLimits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	stubTokenNotRevoked(store)
+
+	config := newTestConfig()
+	config.RateLimitUserRequests = 1
+	config.RateLimitWindow = time.Minute
+	limits, err := ratelimit.NewLimits(config, store)
+	require.NoError(t, err)
+
+	// the servers built on the same limits count the hits of a user together
+	username := util.RandomOwner()
+	codes := make([]int, 0, 2)
+	for i := 0; i < 2; i++ {
+		server, err := NewServer(config, store, limits)
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, "/accounts/0", nil)
+		require.NoError(t, err)
+		addAuthorization(t, request, server.TokenMaker, authorizationTypeBearer, username, util.DepositorRole, time.Minute)
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		codes = append(codes, recorder.Code)
+	}
+	require.Equal(t, []int{http.StatusBadRequest, http.StatusTooManyRequests}, codes)
+}
+
+// failingBackend is a rate limit backend that is down
+type failingBackend struct{}
+
+var errBackendDown = errors.New("backend down")
+
+func (failingBackend) Increment(ctx context.Context, key string, window time.Duration) (ratelimit.Window, error) {
+	return ratelimit.Window{}, errBackendDown
+}
+
+func (failingBackend) Reset(ctx context.Context, key string) error {
+	return errBackendDown
+}
+
+func TestRateLimitMiddlewareBackendError(t *testing.T) {
+	router := gin.New()
+	limiter := ratelimit.NewLimiter(failingBackend{}, "ip:", 1, time.Minute)
+	router.GET("/limited", rateLimitMiddleware(limiter, clientIPKey), func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{})
+	})
+
+	// a broken backend lets the requests through rather than taking the API down
+	for i := 0; i < 2; i++ {
+		request, err := http.NewRequest(http.MethodGet, "/limited", nil)
+		require.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	}
+}
@@ -1,70 +1,179 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"github.com/yuuban007/simplebank/access"
+	"github.com/yuuban007/simplebank/db/migration"
 	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/fx"
+	"github.com/yuuban007/simplebank/ratelimit"
 	"github.com/yuuban007/simplebank/token"
 	"github.com/yuuban007/simplebank/util"
 )
 
 // Server server HTTP requests for banking service.
 type Server struct {
-	config     util.Config
-	store      db.Store
-	TokenMaker token.Maker
-	router     *gin.Engine
+	config           util.Config
+	store            db.Store
+	TokenMaker       token.Maker
+	fxRates          fx.FXRateProvider
+	cursors          *cursorCodec
+	migrationVersion int64
+	ipLimiter        *ratelimit.Limiter
+	authIPLimiter    *ratelimit.Limiter
+	userLimiter      *ratelimit.Limiter
+	authenticator    *access.Authenticator
+	router           *gin.Engine
+	httpServer       *http.Server
 }
 
-// NewServer creates a new HTTP server and setup routing.
-func NewServer(config util.Config, store db.Store) (*Server, error) {
-
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
-	// tokenMaker, err := token.NewJWTMaker(config.TokenSymmetricKey)
+// NewServer creates a new HTTP server and setup routing, the limits may be shared with the gRPC server.
+func NewServer(config util.Config, store db.Store, limits *ratelimit.Limits) (*Server, error) {
 
+	tokenMaker, err := token.NewMaker(config)
 	if err != nil {
 		return nil, fmt.Errorf("can not create token maker %w", err)
 	}
 
+	fxRates, err := fx.NewFXRateProvider(config)
+	if err != nil {
+		return nil, fmt.Errorf("can not create exchange rate provider %w", err)
+	}
+
+	cursors, err := newCursorCodec(config.CursorSecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("can not create cursor codec %w", err)
+	}
+
+	migrationVersion, err := migration.LatestVersion()
+	if err != nil {
+		return nil, fmt.Errorf("can not read migration version %w", err)
+	}
+
 	server := &Server{
-		config:     config,
-		store:      store,
-		TokenMaker: tokenMaker,
+		config:           config,
+		store:            store,
+		TokenMaker:       tokenMaker,
+		fxRates:          fxRates,
+		cursors:          cursors,
+		migrationVersion: migrationVersion,
+		ipLimiter:        limits.IP,
+		authIPLimiter:    limits.AuthIP,
+		userLimiter:      limits.User,
+		authenticator:    access.NewAuthenticator(store, limits.Lockout),
 	}
 
 	// binding validator
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currency", validCurrency)
+		v.RegisterTagNameFunc(requestFieldName)
 	}
 
 	server.setUpRouter()
+	// the per IP limits count the client IP, only the proxies in front of the server may set it
+	err = server.router.SetTrustedProxies(config.TrustedProxyList())
+	if err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies %w", err)
+	}
+	server.httpServer = &http.Server{
+		Handler:      server.router,
+		ReadTimeout:  config.HTTPReadTimeout,
+		WriteTimeout: config.HTTPWriteTimeout,
+		IdleTimeout:  config.HTTPIdleTimeout,
+	}
 	return server, nil
 }
 
 func (server *Server) setUpRouter() {
-	router := gin.Default()
-	router.POST("/users", server.createUser)
-	router.POST("/users/login", server.loginUser)
-
-	authRoutes := router.Group("/").Use(authMiddleware(server.TokenMaker))
+	router := gin.New()
+	// handlers pass the gin context to the store, it must carry the span of the request
+	router.ContextWithFallback = true
+	logger := slog.Default()
+	router.Use(
+		tracingMiddleware(),
+		requestIDMiddleware(),
+		loggerMiddleware(logger),
+		recoveryMiddleware(logger),
+		metricsMiddleware(),
+	)
+	router.NoRoute(func(ctx *gin.Context) {
+		abortWithError(ctx, newAPIError(http.StatusNotFound, codeRouteNotFound, "no route for %s %s", ctx.Request.Method, ctx.Request.URL.Path))
+	})
+
+	router.GET("/healthz", server.getHealth)
+	router.GET("/readyz", server.getReadiness)
+
+	// the routes open to anonymous clients are limited per IP, the others per IP
+	// before the token is checked and then per user
+	ipLimit := rateLimitMiddleware(server.ipLimiter, clientIPKey)
+	router.POST("/users", ipLimit, server.createUser)
+	router.POST("/users/login", ipLimit, server.loginUser)
+	router.POST("/tokens/renew_access", ipLimit, server.renewAccessToken)
+	router.GET("/.well-known/jwks.json", server.getJWKS)
+	router.GET("/openapi.json", server.getOpenAPI)
+	router.GET("/swagger/*filepath", server.getSwaggerUI)
+
+	authRoutes := router.Group("/").Use(
+		rateLimitMiddleware(server.authIPLimiter, clientIPKey),
+		authMiddleware(server.TokenMaker, server.store),
+		rateLimitMiddleware(server.userLimiter, usernameKey),
+	)
+
+	authRoutes.POST("/users/logout", server.logoutUser)
+	authRoutes.POST("/users/logout_all", server.logoutAllUser)
 
 	authRoutes.POST("/accounts", server.createAccount)
 	authRoutes.GET("/accounts/:id", server.getAccount)
 	authRoutes.GET("accounts", server.listAccount)
-
-	router.POST("/transfers", server.createTransfer)
+	authRoutes.GET("/accounts/:id/entries", server.listEntries)
+	authRoutes.GET("/accounts/:id/statement", server.getStatement)
+	authRoutes.POST("/accounts/:id/freeze", authorizeMiddleware(util.BankerRole, util.AdminRole), server.freezeAccount)
+	authRoutes.POST("/accounts/:id/unfreeze", authorizeMiddleware(util.BankerRole, util.AdminRole), server.unfreezeAccount)
+	authRoutes.POST("/accounts/:id/close", server.closeAccount)
+	authRoutes.PUT("/accounts/:id/overdraft_limit", authorizeMiddleware(util.BankerRole, util.AdminRole), server.updateOverdraftLimit)
+	authRoutes.POST("/accounts/:id/deposits", authorizeMiddleware(util.BankerRole, util.AdminRole), server.createDeposit)
+	authRoutes.POST("/accounts/:id/withdrawals", server.createWithdrawal)
+
+	authRoutes.POST("/transfers", server.createTransfer)
+	authRoutes.GET("/transfers", server.listTransfers)
+	authRoutes.GET("/transfers/:id", server.getTransfer)
 
 	server.router = router
 }
 
-// Start run the HTTP server on a specific address.
+// Start runs the HTTP server on a specific address until Shutdown is called.
 func (server *Server) Start(address string) error {
-	return server.router.Run(address)
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s %w", address, err)
+	}
+	return server.serve(listener)
 }
 
-func errorResponse(err error) gin.H {
-	return gin.H{"err": err.Error()}
+func (server *Server) serve(listener net.Listener) error {
+	err := server.httpServer.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops accepting connections and waits for the requests in flight to finish,
+// the connections still active when the context is done are closed.
+func (server *Server) Shutdown(ctx context.Context) error {
+	err := server.httpServer.Shutdown(ctx)
+	if err != nil {
+		// the drain period is over, drop whatever is left
+		server.httpServer.Close()
+	}
+	return err
 }
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/yuuban007/simplebank/db/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+)
+
+func TestServerShutdownDrainsRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(mockdb.NewMockStore(ctrl), t)
+
+	started := make(chan struct{})
+	server.router.GET("/slow", func(ctx *gin.Context) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		ctx.String(http.StatusOK, "done")
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	served := make(chan error, 1)
+	go func() {
+		served <- server.serve(listener)
+	}()
+
+	type result struct {
+		rsp *http.Response
+		err error
+	}
+	requested := make(chan result, 1)
+	go func() {
+		rsp, err := http.Get("http://" + listener.Addr().String() + "/slow")
+		requested <- result{rsp, err}
+	}()
+	<-started
+
+	err = server.Shutdown(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, <-served)
+
+	// the request in flight when the shutdown started still gets its response
+	res := <-requested
+	require.NoError(t, res.err)
+	defer res.rsp.Body.Close()
+	require.Equal(t, http.StatusOK, res.rsp.StatusCode)
+	body, err := io.ReadAll(res.rsp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "done", string(body))
+
+	// new connections are refused
+	_, err = http.Get("http://" + listener.Addr().String() + "/slow")
+	require.Error(t, err)
+}
+
+func TestServerShutdownTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(mockdb.NewMockStore(ctrl), t)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	server.router.GET("/stuck", func(ctx *gin.Context) {
+		close(started)
+		<-release
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.serve(listener)
+	go http.Get("http://" + listener.Addr().String() + "/stuck")
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = server.Shutdown(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	}()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(mockdb.NewMockStore(ctrl), t)
+
+	// the handlers give the gin context to the store, so that is where the span must be found
+	var handlerSpan trace.SpanContext
+	server.router.GET("/traced/:id", func(ctx *gin.Context) {
+		handlerSpan = trace.SpanContextFromContext(ctx)
+		ctx.Status(http.StatusOK)
+	})
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	request, err := http.NewRequest(http.MethodGet, "/traced/1", nil)
+	require.NoError(t, err)
+	request.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	server.router.ServeHTTP(httptest.NewRecorder(), request)
+
+	require.True(t, handlerSpan.IsValid())
+	require.Equal(t, traceID, handlerSpan.TraceID().String())
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "/traced/:id", spans[0].Name())
+	require.Equal(t, traceID, spans[0].Parent().TraceID().String())
+	require.True(t, spans[0].Parent().IsRemote())
+
+	// the probes are left out
+	request, err = http.NewRequest(http.MethodGet, "/healthz", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(httptest.NewRecorder(), request)
+	require.Len(t, recorder.Ended(), 1)
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yuuban007/simplebank/access"
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/statement"
+	"github.com/yuuban007/simplebank/token"
+)
+
+const statementFormatJSON = "json"
+
+// defaultStatementPeriod is how far back a statement goes when the from time isn't given
+const defaultStatementPeriod = 30 * 24 * time.Hour
+
+type getStatementURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type getStatementRequest struct {
+	From   time.Time `form:"from"`
+	To     time.Time `form:"to"`
+	Format string    `form:"format" binding:"omitempty,oneof=json csv ofx pdf"`
+}
+
+type statementEntry struct {
+	ID                    int64     `json:"id"`
+	Amount                int64     `json:"amount"`
+	Balance               int64     `json:"balance"`
+	TransferID            *int64    `json:"transfer_id,omitempty"`
+	CounterpartyAccountID *int64    `json:"counterparty_account_id,omitempty"`
+	CounterpartyOwner     string    `json:"counterparty_owner,omitempty"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+type statementResponse struct {
+	AccountID      int64            `json:"account_id"`
+	Currency       string           `json:"currency"`
+	From           time.Time        `json:"from"`
+	To             time.Time        `json:"to"`
+	OpeningBalance int64            `json:"opening_balance"`
+	ClosingBalance int64            `json:"closing_balance"`
+	Entries        []statementEntry `json:"entries"`
+}
+
+func newStatementResponse(arg db.AccountStatementTxParams, statement db.AccountStatementTxResult) statementResponse {
+	rsp := statementResponse{
+		AccountID:      statement.Account.ID,
+		Currency:       statement.Account.Currency,
+		From:           arg.From,
+		To:             arg.To,
+		OpeningBalance: statement.OpeningBalance,
+		ClosingBalance: statement.ClosingBalance,
+		Entries:        make([]statementEntry, 0, len(statement.Lines)),
+	}
+	for _, line := range statement.Lines {
+		entry := statementEntry{
+			ID:                line.ID,
+			Amount:            line.Amount,
+			Balance:           line.Balance,
+			TransferID:        line.TransferID,
+			CounterpartyOwner: line.CounterpartyOwner.String,
+			CreatedAt:         line.CreatedAt,
+		}
+		if line.CounterpartyAccountID.Valid {
+			counterpartyAccountID := line.CounterpartyAccountID.Int64
+			entry.CounterpartyAccountID = &counterpartyAccountID
+		}
+		rsp.Entries = append(rsp.Entries, entry)
+	}
+	return rsp
+}
+
+// getStatement lists the entries of an account within [from, to) with the running balance after each of them.
+// The statement is exported as CSV, OFX or PDF instead of JSON when asked by the format parameter or the Accept header.
+func (server *Server) getStatement(ctx *gin.Context) {
+	var uri getStatementURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+	var req getStatementRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+
+	arg := db.AccountStatementTxParams{
+		AccountID: uri.ID,
+		From:      req.From,
+		To:        req.To,
+	}
+	if arg.To.IsZero() {
+		arg.To = time.Now()
+	}
+	if arg.From.IsZero() {
+		arg.From = arg.To.Add(-defaultStatementPeriod)
+	}
+	if !arg.From.Before(arg.To) {
+		abortWithError(ctx, newAPIError(http.StatusBadRequest, codeInvalidPeriod, "from must be before to"))
+		return
+	}
+
+	format := req.Format
+	if format == "" {
+		format = negotiateStatementFormat(ctx)
+	}
+	if format != statementFormatJSON {
+		server.exportStatement(ctx, arg, format)
+		return
+	}
+
+	// the owner is checked before the statement is computed, like the exports do before writing it
+	account, valid := server.findAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !access.CanViewAccount(authPayload, account) {
+		abortWithError(ctx, errAccountNotOwned)
+		return
+	}
+
+	result, err := server.store.AccountStatementTx(ctx, arg)
+	if err != nil {
+		abortWithError(ctx, notFoundError(err, codeAccountNotFound, "account [%d] not found", uri.ID))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newStatementResponse(arg, result))
+}
+
+// negotiateStatementFormat picks the statement format from the Accept header, JSON by default
+func negotiateStatementFormat(ctx *gin.Context) string {
+	contentType := ctx.NegotiateFormat(
+		gin.MIMEJSON,
+		statement.ContentTypes[statement.CSV],
+		statement.ContentTypes[statement.OFX],
+		statement.ContentTypes[statement.PDF],
+	)
+	for format, formatContentType := range statement.ContentTypes {
+		if contentType == formatContentType {
+			return format
+		}
+	}
+	return statementFormatJSON
+}
+
+// exportStatement streams the statement to the client while it is read from the database
+func (server *Server) exportStatement(ctx *gin.Context, arg db.AccountStatementTxParams, format string) {
+	renderer, err := statement.NewWriter(format, ctx.Writer)
+	if err != nil {
+		abortWithError(ctx, &apiError{Status: http.StatusBadRequest, Code: codeInvalidRequest, Message: err.Error(), Err: err})
+		return
+	}
+	extendWriteDeadline(ctx, server.config.HTTPExportTimeout)
+
+	export := &statementExport{
+		StatementWriter: renderer,
+		ctx:             ctx,
+		authPayload:     ctx.MustGet(authorizationPayloadKey).(*token.Payload),
+		format:          format,
+	}
+	err = server.store.StreamAccountStatementTx(ctx, arg, export)
+	if err == nil {
+		return
+	}
+	if ctx.Writer.Written() {
+		// the status is already sent, the truncated body is all the client gets
+		ctx.Error(err)
+		return
+	}
+	ctx.Writer.Header().Del("Content-Type")
+	ctx.Writer.Header().Del("Content-Disposition")
+	abortWithError(ctx, notFoundError(err, codeAccountNotFound, "account [%d] not found", arg.AccountID))
+}
+
+// extendWriteDeadline replaces the write timeout of the server for a long streamed response,
+// a zero timeout lifts the deadline
+func extendWriteDeadline(ctx *gin.Context, timeout time.Duration) {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	err := http.NewResponseController(ctx.Writer).SetWriteDeadline(deadline)
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		ctx.Error(err)
+	}
+}
+
+// statementExport checks that the account can be viewed before the renderer starts writing the response
+type statementExport struct {
+	db.StatementWriter
+	ctx         *gin.Context
+	authPayload *token.Payload
+	format      string
+}
+
+func (export *statementExport) WriteHeader(header db.StatementHeader) error {
+	if !access.CanViewAccount(export.authPayload, header.Account) {
+		return errAccountNotOwned
+	}
+
+	filename := fmt.Sprintf("statement-%d-%s-%s.%s",
+		header.Account.ID, header.From.UTC().Format("20060102"), header.To.UTC().Format("20060102"), export.format)
+	export.ctx.Header("Content-Type", statement.ContentTypes[export.format])
+	export.ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	export.ctx.Status(http.StatusOK)
+	return export.StatementWriter.WriteHeader(header)
+}
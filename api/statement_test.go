@@ -0,0 +1,517 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/yuuban007/simplebank/db/mock"
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/token"
+	"github.com/yuuban007/simplebank/util"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetStatementAPI(t *testing.T) {
+	user, _ := randomUser()
+	account := randomAccount(user.Username)
+	counterparty := randomAccount(util.RandomOwner())
+
+	to := time.Now().UTC().Truncate(time.Second)
+	from := to.Add(-time.Hour)
+
+	transferID := util.RandomInt(1, 1000)
+	statement := db.AccountStatementTxResult{
+		Account:        account,
+		OpeningBalance: 100,
+		ClosingBalance: 70,
+		Lines: []db.StatementLine{
+			{
+				ListStatementEntriesRow: db.ListStatementEntriesRow{
+					ID:                    util.RandomInt(1, 1000),
+					Amount:                -50,
+					TransferID:            &transferID,
+					CounterpartyAccountID: pgtype.Int8{Int64: counterparty.ID, Valid: true},
+					CounterpartyOwner:     pgtype.Text{String: counterparty.Owner, Valid: true},
+					CreatedAt:             from.Add(time.Minute),
+				},
+				Balance: 50,
+			},
+			{
+				ListStatementEntriesRow: db.ListStatementEntriesRow{
+					ID:        util.RandomInt(1, 1000),
+					Amount:    20,
+					CreatedAt: from.Add(2 * time.Minute),
+				},
+				Balance: 70,
+			},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		setUpAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			query: url.Values{
+				"from": {from.Format(time.RFC3339)},
+				"to":   {to.Format(time.RFC3339)},
+			},
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				arg := db.AccountStatementTxParams{
+					AccountID: account.ID,
+					From:      from,
+					To:        to,
+				}
+				store.EXPECT().
+					AccountStatementTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(statement, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				rsp := requireBodyStatement(t, recorder.Body)
+				require.Equal(t, account.ID, rsp.AccountID)
+				require.Equal(t, account.Currency, rsp.Currency)
+				require.Equal(t, statement.OpeningBalance, rsp.OpeningBalance)
+				require.Equal(t, statement.ClosingBalance, rsp.ClosingBalance)
+				require.Len(t, rsp.Entries, 2)
+
+				require.Equal(t, int64(50), rsp.Entries[0].Balance)
+				require.Equal(t, transferID, *rsp.Entries[0].TransferID)
+				require.Equal(t, counterparty.ID, *rsp.Entries[0].CounterpartyAccountID)
+				require.Equal(t, counterparty.Owner, rsp.Entries[0].CounterpartyOwner)
+
+				require.Equal(t, int64(70), rsp.Entries[1].Balance)
+				require.Nil(t, rsp.Entries[1].TransferID)
+				require.Nil(t, rsp.Entries[1].CounterpartyAccountID)
+			},
+		},
+		{
+			name:  "DefaultPeriod",
+			query: url.Values{},
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					AccountStatementTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.AccountStatementTxParams) (db.AccountStatementTxResult, error) {
+						require.WithinDuration(t, time.Now(), arg.To, time.Second)
+						require.Equal(t, defaultStatementPeriod, arg.To.Sub(arg.From))
+						return statement, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "BankerViewsAnyStatement",
+			query: url.Values{
+				"from": {from.Format(time.RFC3339)},
+			},
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					AccountStatementTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(statement, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "UnauthorizedUser",
+			query: url.Values{},
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					AccountStatementTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:  "NoAuthorization",
+			query: url.Values{},
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AccountStatementTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:  "NotFound",
+			query: url.Values{},
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(db.Account{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					AccountStatementTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "FromAfterTo",
+			query: url.Values{
+				"from": {to.Format(time.RFC3339)},
+				"to":   {from.Format(time.RFC3339)},
+			},
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AccountStatementTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidTime",
+			query: url.Values{
+				"from": {"yesterday"},
+			},
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AccountStatementTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: url.Values{},
+			setUpAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					AccountStatementTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AccountStatementTxResult{}, pgx.ErrTxClosed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubTokenNotRevoked(store)
+
+			server := newTestServer(store, t)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/statement?%s", account.ID, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			tc.setUpAuth(t, request, server.TokenMaker)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestExportStatementAPI(t *testing.T) {
+	user, _ := randomUser()
+	account := randomAccount(user.Username)
+	account.Currency = util.USD
+
+	line := db.StatementLine{
+		ListStatementEntriesRow: db.ListStatementEntriesRow{
+			ID:        util.RandomInt(1, 1000),
+			Amount:    1250,
+			CreatedAt: time.Now(),
+		},
+		Balance: 2250,
+	}
+
+	// streamStatement plays the part of the store, feeding a statement to the writer
+	streamStatement := func(_ any, arg db.AccountStatementTxParams, w db.StatementWriter) error {
+		err := w.WriteHeader(db.StatementHeader{
+			Account:        account,
+			From:           arg.From,
+			To:             arg.To,
+			OpeningBalance: 1000,
+		})
+		if err != nil {
+			return err
+		}
+		if err := w.WriteLine(line); err != nil {
+			return err
+		}
+		return w.WriteFooter(line.Balance)
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		accept        string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "CSVFormatParameter",
+			query:    "format=csv",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					StreamAccountStatementTx(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(streamStatement)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+				require.Contains(t, recorder.Header().Get("Content-Disposition"), "attachment")
+				require.Contains(t, recorder.Body.String(), "12.50,22.50,USD")
+			},
+		},
+		{
+			name:     "OFXAcceptHeader",
+			accept:   "application/x-ofx",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					StreamAccountStatementTx(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(streamStatement)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "application/x-ofx", recorder.Header().Get("Content-Type"))
+				require.Contains(t, recorder.Body.String(), "<TRNAMT>12.50</TRNAMT>")
+			},
+		},
+		{
+			name:     "PDFAcceptHeader",
+			accept:   "application/pdf",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					StreamAccountStatementTx(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(streamStatement)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "application/pdf", recorder.Header().Get("Content-Type"))
+				require.True(t, bytes.HasPrefix(recorder.Body.Bytes(), []byte("%PDF-")))
+			},
+		},
+		{
+			name:     "FormatParameterOverridesAccept",
+			query:    "format=csv",
+			accept:   "application/pdf",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					StreamAccountStatementTx(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(streamStatement)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			query:    "format=csv",
+			username: "unauthorized_user",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					StreamAccountStatementTx(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(streamStatement)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "12.50")
+				require.Empty(t, recorder.Header().Get("Content-Disposition"))
+			},
+		},
+		{
+			name:     "NotFound",
+			query:    "format=ofx",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					StreamAccountStatementTx(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "UnsupportedFormat",
+			query:    "format=xls",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					StreamAccountStatementTx(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubTokenNotRevoked(store)
+
+			server := newTestServer(store, t)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/statement?%s", account.ID, tc.query)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			if tc.accept != "" {
+				request.Header.Set("Accept", tc.accept)
+			}
+			addAuthorization(t, request, server.TokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestExportStatementOutlastsWriteTimeout(t *testing.T) {
+	user, _ := randomUser()
+	account := randomAccount(user.Username)
+	account.Currency = util.USD
+
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	stubTokenNotRevoked(store)
+	store.EXPECT().
+		StreamAccountStatementTx(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ any, arg db.AccountStatementTxParams, w db.StatementWriter) error {
+			// a slow statement is still being written after the write timeout of the server
+			time.Sleep(200 * time.Millisecond)
+			err := w.WriteHeader(db.StatementHeader{Account: account, From: arg.From, To: arg.To})
+			if err != nil {
+				return err
+			}
+			return w.WriteFooter(0)
+		})
+
+	config := newTestConfig()
+	config.HTTPWriteTimeout = 50 * time.Millisecond
+	config.HTTPExportTimeout = time.Minute
+	server := newTestServerWithConfig(t, config, store)
+
+	httpServer := httptest.NewUnstartedServer(server.router)
+	httpServer.Config.WriteTimeout = config.HTTPWriteTimeout
+	httpServer.Start()
+	defer httpServer.Close()
+
+	url := fmt.Sprintf("%s/accounts/%d/statement?format=csv", httpServer.URL, account.ID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.TokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+
+	response, err := httpServer.Client().Do(request)
+	require.NoError(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.NotEmpty(t, body)
+}
+
+func requireBodyStatement(t *testing.T, body *bytes.Buffer) statementResponse {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var rsp statementResponse
+	err = json.Unmarshal(data, &rsp)
+	require.NoError(t, err)
+	return rsp
+}
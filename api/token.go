@@ -0,0 +1,95 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/token"
+)
+
+type renewAccessTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type renewAccessTokenResponse struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+}
+
+func (server *Server) renewAccessToken(ctx *gin.Context) {
+	var req renewAccessTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+
+	refreshPayload, err := server.TokenMaker.VerifyToken(req.RefreshToken, token.RefreshToken)
+	if err != nil {
+		abortWithError(ctx, tokenError(err))
+		return
+	}
+
+	// check if the session exists
+	session, err := server.store.GetSession(ctx, refreshPayload.ID)
+	if err != nil {
+		abortWithError(ctx, notFoundError(err, codeSessionNotFound, "session not found"))
+		return
+	}
+
+	if session.IsBlocked {
+		abortWithError(ctx, newAPIError(http.StatusUnauthorized, codeInvalidSession, "blocked session"))
+		return
+	}
+
+	if session.Username != refreshPayload.Username {
+		abortWithError(ctx, newAPIError(http.StatusUnauthorized, codeInvalidSession, "incorrect session user"))
+		return
+	}
+
+	if session.RefreshToken != req.RefreshToken {
+		abortWithError(ctx, newAPIError(http.StatusUnauthorized, codeInvalidSession, "mismatched session token"))
+		return
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		abortWithError(ctx, newAPIError(http.StatusUnauthorized, codeInvalidSession, "expired session"))
+		return
+	}
+
+	// the new access token carries the current role of the user, not the one at login,
+	// so a demoted user loses the rights of the old role at the next renewal
+	user, err := server.store.GetUser(ctx, session.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			abortWithError(ctx, newAPIError(http.StatusUnauthorized, codeInvalidSession, "session user not found"))
+			return
+		}
+		abortWithError(ctx, err)
+		return
+	}
+
+	accessToken, accessPayload, err := server.TokenMaker.CreateToken(user.Username, user.Role, token.AccessToken, server.config.AccessTokenDuration)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	response := renewAccessTokenResponse{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// getJWKS publishes the public keys of the token maker so other services can verify the tokens
+func (server *Server) getJWKS(ctx *gin.Context) {
+	maker, ok := server.TokenMaker.(token.PublicKeyMaker)
+	if !ok {
+		abortWithError(ctx, newAPIError(http.StatusNotFound, codePublicKeysNotFound, "tokens are not signed with a public key"))
+		return
+	}
+	ctx.JSON(http.StatusOK, maker.PublicKeys())
+}
@@ -1,14 +1,24 @@
 package api
 
 import (
-	"database/sql"
-	"errors"
-	"fmt"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/yuuban007/simplebank/access"
 	db "github.com/yuuban007/simplebank/db/sqlc"
 	"github.com/yuuban007/simplebank/token"
+	"github.com/yuuban007/simplebank/util"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
 )
 
 type createTransferRequest struct {
@@ -22,7 +32,7 @@ type createTransferRequest struct {
 func (server *Server) createTransfer(ctx *gin.Context) {
 	var req createTransferRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(ctx, bindingError(err))
 		return
 	}
 
@@ -33,12 +43,11 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 	}
 
 	if fromAccount.Owner != authPayload.Username {
-		err := errors.New("from account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		abortWithError(ctx, newAPIError(http.StatusUnauthorized, codeAccountNotOwned, "from account doesn't belong to the authenticated user"))
 		return
 	}
 
-	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	toAccount, valid := server.findAccount(ctx, req.ToAccountID)
 	if !valid {
 		return
 	}
@@ -48,29 +57,204 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		ToAccountID:   req.ToAccountID,
 		Amount:        req.Amount,
 	}
+
+	if toAccount.Currency != fromAccount.Currency {
+		rate, err := server.fxRates.Rate(ctx, fromAccount.Currency, toAccount.Currency)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+
+		arg.ExchangeRate = rate.String()
+		arg.ConvertedAmount, err = rate.Convert(req.Amount)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		if arg.ConvertedAmount <= 0 {
+			abortWithError(ctx, newAPIError(http.StatusBadRequest, codeAmountTooSmall,
+				"amount %d %s is too small to convert to %s", req.Amount, fromAccount.Currency, toAccount.Currency))
+			return
+		}
+	}
+
+	idempotencyKey := ctx.GetHeader(idempotencyKeyHeader)
+	if idempotencyKey != "" {
+		server.createIdempotentTransfer(ctx, idempotencyKey, authPayload.Username, req, arg)
+		return
+	}
+
 	result, err := server.store.TransferTx(ctx, arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, result)
 }
 
-func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
+// createIdempotentTransfer executes the transfer at most once for the idempotency key,
+// so that a client can safely retry a transfer after a timeout
+func (server *Server) createIdempotentTransfer(
+	ctx *gin.Context,
+	idempotencyKey string,
+	username string,
+	req createTransferRequest,
+	arg db.TransferTxParams,
+) {
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		abortWithError(ctx, newAPIError(http.StatusBadRequest, codeInvalidRequest,
+			"%s header must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength))
+		return
+	}
+
+	requestHash, err := hashRequest(req)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	result, err := server.store.IdempotentTransferTx(ctx, db.IdempotentTransferTxParams{
+		TransferTxParams: arg,
+		Username:         username,
+		Key:              idempotencyKey,
+		RequestHash:      requestHash,
+		ExpiresAt:        time.Now().Add(server.config.IdempotencyKeyTTL),
+	})
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	if result.Replayed {
+		ctx.Header(idempotentReplayedHeader, "true")
+	}
+	ctx.JSON(http.StatusOK, result.TransferTxResult)
+}
+
+// hashRequest returns the sha256 of the json encoded request
+func hashRequest(req any) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// findAccount gets the account of a transfer, writing the error response when it can't
+func (server *Server) findAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
 	account, err := server.store.GetAccount(ctx, accountID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return account, false
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(ctx, notFoundError(err, codeAccountNotFound, "account [%d] not found", accountID))
+		return account, false
+	}
+	return account, true
+}
+
+func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
+	account, valid := server.findAccount(ctx, accountID)
+	if !valid {
 		return account, false
 	}
 
 	if account.Currency != currency {
-		err := fmt.Errorf("account [%d] currency currency mismatch: %s vs %s", accountID, account.Currency, currency)
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(ctx, newAPIError(http.StatusBadRequest, codeCurrencyMismatch,
+			"account [%d] currency mismatch: %s vs %s", accountID, account.Currency, currency))
 		return account, false
 	}
 	return account, true
 }
+
+type listTransfersRequest struct {
+	AccountID int64     `form:"account_id" binding:"omitempty,min=1"`
+	Direction string    `form:"direction" binding:"omitempty,oneof=in out"`
+	MinAmount int64     `form:"min_amount" binding:"omitempty,gt=0"`
+	MaxAmount int64     `form:"max_amount" binding:"omitempty,gt=0,gtefield=MinAmount"`
+	Currency  string    `form:"currency" binding:"omitempty,currency"`
+	From      time.Time `form:"from"`
+	To        time.Time `form:"to"`
+	pageRequest
+}
+
+type listTransfersResponse struct {
+	Transfers  []db.Transfer `json:"transfers"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// listTransfers lists the transfers touching the accounts of the authenticated user, bank staff see all transfers.
+// The amount range and the currency apply to the amount sent by the from account.
+func (server *Server) listTransfers(ctx *gin.Context) {
+	var req listTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+	page, err := server.cursors.page(cursorKindTransfers, req.pageRequest)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.ListTransfersParams{
+		Owner:          pgtype.Text{String: authPayload.Username, Valid: !util.IsStaffRole(authPayload.Role)},
+		Direction:      pgtype.Text{String: req.Direction, Valid: req.Direction != ""},
+		AccountID:      pgtype.Int8{Int64: req.AccountID, Valid: req.AccountID != 0},
+		MinAmount:      pgtype.Int8{Int64: req.MinAmount, Valid: req.MinAmount != 0},
+		MaxAmount:      pgtype.Int8{Int64: req.MaxAmount, Valid: req.MaxAmount != 0},
+		Currency:       pgtype.Text{String: req.Currency, Valid: req.Currency != ""},
+		FromTime:       pgtype.Timestamptz{Time: req.From, Valid: !req.From.IsZero()},
+		ToTime:         pgtype.Timestamptz{Time: req.To, Valid: !req.To.IsZero()},
+		AfterCreatedAt: page.AfterCreatedAt,
+		AfterID:        page.AfterID,
+		Limit:          page.Limit,
+		Offset:         page.Offset,
+	}
+	transfers, err := server.store.ListTransfers(ctx, arg)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+
+	rsp := listTransfersResponse{Transfers: transfers}
+	if n := len(transfers); n > 0 {
+		last := transfers[n-1]
+		rsp.NextCursor, err = server.cursors.nextCursor(cursorKindTransfers, req.pageRequest, n, last.ID, last.CreatedAt)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type getTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getTransfer(ctx *gin.Context) {
+	var req getTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		abortWithError(ctx, bindingError(err))
+		return
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, req.ID)
+	if err != nil {
+		abortWithError(ctx, notFoundError(err, codeTransferNotFound, "transfer [%d] not found", req.ID))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	allowed, err := access.CanViewTransfer(ctx, server.store, authPayload, transfer)
+	if err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+	if !allowed {
+		abortWithError(ctx, errTransferNotOwned)
+		return
+	}
+	ctx.JSON(http.StatusOK, transfer)
+}
@@ -1,13 +1,15 @@
 package api
 
 import (
-	"database/sql"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/lib/pq"
+	"github.com/google/uuid"
+	"github.com/yuuban007/simplebank/access"
 	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/token"
 	"github.com/yuuban007/simplebank/util"
 )
 
@@ -22,6 +24,7 @@ type UserResponse struct {
 	Username          string    `json:"username"`
 	FullName          string    `json:"full_name"`
 	Email             string    `json:"email"`
+	Role              string    `json:"role"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 }
@@ -31,6 +34,7 @@ func newUserResponse(user db.User) UserResponse {
 		Username:          user.Username,
 		FullName:          user.FullName,
 		Email:             user.Email,
+		Role:              user.Role,
 		PasswordChangedAt: user.PasswordChangedAt,
 		CreatedAt:         user.CreatedAt,
 	}
@@ -39,13 +43,13 @@ func newUserResponse(user db.User) UserResponse {
 func (server *Server) createUser(ctx *gin.Context) {
 	var req createUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(ctx, bindingError(err))
 		return
 	}
 
 	hashedPassword, err2 := util.HashPassword(req.Password)
 	if err2 != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err2))
+		abortWithError(ctx, err2)
 		return
 	}
 	arg := db.CreateUserParams{
@@ -56,15 +60,7 @@ func (server *Server) createUser(ctx *gin.Context) {
 	}
 	user, err := server.store.CreateUser(ctx, arg)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			// log.Println(pqErr.Code.Name())
-			switch pqErr.Code.Name() {
-			case "unique_violation":
-				ctx.JSON(http.StatusForbidden, errorResponse(err))
-				return
-			}
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(ctx, alreadyExistsError(err, codeUserAlreadyExists, "username or email already exists"))
 		return
 	}
 	response := newUserResponse(user)
@@ -76,40 +72,117 @@ type loginUserRequest struct {
 	Password string `json:"password" binding:"required,min=8"`
 }
 type loginUserResponse struct {
-	AccessToken string       `json:"access_token"`
-	User        UserResponse `json:"user"`
+	SessionID             uuid.UUID    `json:"session_id"`
+	AccessToken           string       `json:"access_token"`
+	AccessTokenExpiresAt  time.Time    `json:"access_token_expires_at"`
+	RefreshToken          string       `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at"`
+	User                  UserResponse `json:"user"`
 }
 
 func (server *Server) loginUser(ctx *gin.Context) {
 	var req loginUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		abortWithError(ctx, bindingError(err))
 		return
 	}
 
-	// check if the user exists
-	user, err := server.store.GetUser(ctx, req.Username)
+	user, err := server.authenticator.Login(ctx, req.Username, req.Password)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		var lockedErr *access.LoginLockedError
+		if errors.As(err, &lockedErr) {
+			abortTooManyRequests(ctx, lockedErr.RetryAfter, codeLoginLocked, lockedErr.Error())
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(ctx, err)
+		return
+	}
+
+	accessToken, accessPayload, err := server.TokenMaker.CreateToken(user.Username, user.Role, token.AccessToken, server.config.AccessTokenDuration)
+	if err != nil {
+		abortWithError(ctx, err)
 		return
 	}
 
-	// check if the password is correct
-	if err := util.CheckPassword(req.Password, user.HashedPassword); err != nil {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	refreshToken, refreshPayload, err := server.TokenMaker.CreateToken(user.Username, user.Role, token.RefreshToken, server.config.RefreshTokenDuration)
+	if err != nil {
+		abortWithError(ctx, err)
 		return
 	}
-	accessToken, err := server.TokenMaker.CreateToken(req.Username, server.config.AccessTokenDuration)
+
+	// the refresh token id is used as the session id
+	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
+		ID:           refreshPayload.ID,
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		UserAgent:    ctx.Request.UserAgent(),
+		ClientIp:     ctx.ClientIP(),
+		IsBlocked:    false,
+		ExpiresAt:    refreshPayload.ExpiredAt,
+	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		abortWithError(ctx, err)
+		return
 	}
+
 	response := loginUserResponse{
-		AccessToken: accessToken,
-		User:        newUserResponse(user),
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
+		User:                  newUserResponse(user),
 	}
 	ctx.JSON(http.StatusOK, response)
 }
+
+type logoutUserRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (server *Server) logoutUser(ctx *gin.Context) {
+	var req logoutUserRequest
+	// the body is optional, only the session of the given refresh token will be blocked
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			abortWithError(ctx, bindingError(err))
+			return
+		}
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	arg := db.LogoutTxParams{
+		Username:  authPayload.Username,
+		TokenID:   authPayload.ID,
+		ExpiresAt: authPayload.ExpiredAt,
+	}
+
+	if req.RefreshToken != "" {
+		refreshPayload, err := server.TokenMaker.VerifyToken(req.RefreshToken, token.RefreshToken)
+		if err != nil {
+			abortWithError(ctx, tokenError(err))
+			return
+		}
+		if refreshPayload.Username != authPayload.Username {
+			abortWithError(ctx, newAPIError(http.StatusUnauthorized, codeInvalidToken, "refresh token doesn't belong to the authenticated user"))
+			return
+		}
+		arg.SessionID = uuid.NullUUID{UUID: refreshPayload.ID, Valid: true}
+	}
+
+	if err := server.store.LogoutTx(ctx, arg); err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (server *Server) logoutAllUser(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if err := server.store.LogoutAllTx(ctx, authPayload.Username); err != nil {
+		abortWithError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN "status";
+
+ALTER TABLE IF EXISTS "users" DROP COLUMN "role";
@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN "exchange_rate";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN "converted_amount";
+
+COMMENT ON COLUMN "transfers"."amount" IS 'must be positive';
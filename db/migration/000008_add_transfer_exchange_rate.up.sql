@@ -0,0 +1,13 @@
+ALTER TABLE "transfers" ADD COLUMN "converted_amount" bigint;
+
+ALTER TABLE "transfers" ADD COLUMN "exchange_rate" numeric NOT NULL DEFAULT 1;
+
+UPDATE "transfers" SET "converted_amount" = "amount";
+
+ALTER TABLE "transfers" ALTER COLUMN "converted_amount" SET NOT NULL;
+
+COMMENT ON COLUMN "transfers"."amount" IS 'must be positive, in the currency of the from account';
+
+COMMENT ON COLUMN "transfers"."converted_amount" IS 'amount in the currency of the to account';
+
+COMMENT ON COLUMN "transfers"."exchange_rate" IS 'rate applied to convert amount into converted_amount';
@@ -0,0 +1,19 @@
+-- the entries of the settlement accounts balance the cash movements of the customers,
+-- dropping them would leave the customer balances unbacked so the migration refuses to
+DO $$
+BEGIN
+  IF EXISTS (
+    SELECT 1 FROM "entries"
+    WHERE "account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'simplebank')
+  ) OR EXISTS (
+    SELECT 1 FROM "transfers"
+    WHERE "from_account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'simplebank')
+       OR "to_account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'simplebank')
+  ) THEN
+    RAISE EXCEPTION 'settlement accounts have entries or transfers, they can''t be removed';
+  END IF;
+END $$;
+
+DELETE FROM "accounts" WHERE "owner" = 'simplebank';
+
+DELETE FROM "users" WHERE "username" = 'simplebank';
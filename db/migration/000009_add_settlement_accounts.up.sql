@@ -0,0 +1,10 @@
+-- the bank owns one settlement account per currency, balancing the entries of cash deposits and withdrawals.
+-- its user can't log in and its service role grants no staff privileges
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email", "role")
+VALUES ('simplebank', '!', 'Simple Bank Settlement', 'settlement@simplebank.internal', 'service');
+
+INSERT INTO "accounts" ("owner", "balance", "currency")
+VALUES
+  ('simplebank', 0, 'USD'),
+  ('simplebank', 0, 'EUR'),
+  ('simplebank', 0, 'CAD');
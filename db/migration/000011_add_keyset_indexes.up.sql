@@ -0,0 +1,7 @@
+-- keyset pagination walks the listings in (created_at, id) order
+CREATE INDEX ON "accounts" ("owner", "created_at", "id");
+
+CREATE INDEX ON "transfers" ("created_at", "id");
+
+DROP INDEX IF EXISTS "entries_account_id_created_at_idx";
+CREATE INDEX ON "entries" ("account_id", "created_at", "id");
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS "owner_currency_key";
+ALTER TABLE "accounts" ADD CONSTRAINT "owner_currency_key" UNIQUE ("owner", "currency");
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT "account_status_valid";
+
+COMMENT ON COLUMN "accounts"."status" IS 'active or frozen';
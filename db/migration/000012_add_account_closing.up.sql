@@ -0,0 +1,7 @@
+ALTER TABLE "accounts" ADD CONSTRAINT "account_status_valid" CHECK ("status" IN ('active', 'frozen', 'closed'));
+
+-- a closed account doesn't keep its owner from opening a new one in the same currency
+ALTER TABLE "accounts" DROP CONSTRAINT "owner_currency_key";
+CREATE UNIQUE INDEX "owner_currency_key" ON "accounts" ("owner", "currency") WHERE "status" <> 'closed';
+
+COMMENT ON COLUMN "accounts"."status" IS 'active, frozen or closed';
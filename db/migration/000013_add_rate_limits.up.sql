@@ -0,0 +1,11 @@
+CREATE TABLE "rate_limits" (
+  "key" varchar PRIMARY KEY,
+  "hits" bigint NOT NULL,
+  "reset_at" timestamptz NOT NULL
+);
+
+CREATE INDEX ON "rate_limits" ("reset_at");
+
+COMMENT ON COLUMN "rate_limits"."key" IS 'what is limited, e.g. ip:203.0.113.7 or login:alice';
+
+COMMENT ON COLUMN "rate_limits"."reset_at" IS 'end of the current window, the hits count again from zero after it';
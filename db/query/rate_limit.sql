@@ -0,0 +1,20 @@
+-- name: IncrementRateLimit :one
+INSERT INTO rate_limits (
+  key,
+  hits,
+  reset_at
+) VALUES (
+  sqlc.arg(key), 1, sqlc.arg(reset_at)
+)
+ON CONFLICT (key) DO UPDATE
+SET hits = CASE WHEN rate_limits.reset_at <= sqlc.arg(now) THEN 1 ELSE rate_limits.hits + 1 END,
+    reset_at = CASE WHEN rate_limits.reset_at <= sqlc.arg(now) THEN EXCLUDED.reset_at ELSE rate_limits.reset_at END
+RETURNING *;
+
+-- name: DeleteRateLimit :exec
+DELETE FROM rate_limits
+WHERE key = $1;
+
+-- name: DeleteExpiredRateLimits :exec
+DELETE FROM rate_limits
+WHERE reset_at <= $1;
@@ -0,0 +1,38 @@
+-- name: CreateRevokedToken :one
+INSERT INTO revoked_tokens (
+  id,
+  username,
+  expires_at
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (id) DO UPDATE
+SET expires_at = EXCLUDED.expires_at
+RETURNING *;
+
+-- name: RevokeUserTokens :one
+INSERT INTO user_token_revocations (
+  username,
+  revoked_before
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (username) DO UPDATE
+SET revoked_before = EXCLUDED.revoked_before
+RETURNING *;
+
+-- name: IsTokenRevoked :one
+SELECT (
+  EXISTS (
+    SELECT 1 FROM revoked_tokens
+    WHERE revoked_tokens.id = sqlc.arg(id)
+  ) OR EXISTS (
+    SELECT 1 FROM user_token_revocations
+    WHERE user_token_revocations.username = sqlc.arg(username)
+      AND user_token_revocations.revoked_before >= sqlc.arg(issued_at)
+  )
+)::boolean AS revoked;
+
+-- name: DeleteExpiredRevokedTokens :exec
+DELETE FROM revoked_tokens
+WHERE username = $1 AND expires_at < now();
@@ -2,9 +2,11 @@
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  converted_amount,
+  exchange_rate
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5
 )
 RETURNING *;
 
@@ -13,10 +15,26 @@ SELECT * FROM transfers
 WHERE id = $1 LIMIT 1;
 
 -- name: ListTransfers :many
-SELECT * FROM transfers
-WHERE 
-    from_account_id = $1 OR
-    to_account_id = $2
-ORDER BY id
-LIMIT $3
-OFFSET $4;
\ No newline at end of file
+-- every filter is optional, direction is 'in' or 'out' relative to the owner and the account.
+-- amount and currency are those of the from account, the currency the transfer was made in.
+-- after_created_at and after_id are the keyset cursor of the last transfer of the previous page.
+SELECT t.* FROM transfers t
+JOIN accounts from_account ON from_account.id = t.from_account_id
+JOIN accounts to_account ON to_account.id = t.to_account_id
+WHERE
+  (sqlc.narg(owner)::varchar IS NULL
+    OR (sqlc.narg(direction)::varchar IS DISTINCT FROM 'in' AND from_account.owner = sqlc.narg(owner))
+    OR (sqlc.narg(direction)::varchar IS DISTINCT FROM 'out' AND to_account.owner = sqlc.narg(owner)))
+  AND (sqlc.narg(account_id)::bigint IS NULL
+    OR (sqlc.narg(direction)::varchar IS DISTINCT FROM 'in' AND t.from_account_id = sqlc.narg(account_id))
+    OR (sqlc.narg(direction)::varchar IS DISTINCT FROM 'out' AND t.to_account_id = sqlc.narg(account_id)))
+  AND (sqlc.narg(min_amount)::bigint IS NULL OR t.amount >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL OR t.amount <= sqlc.narg(max_amount))
+  AND (sqlc.narg(currency)::varchar IS NULL OR from_account.currency = sqlc.narg(currency))
+  AND (sqlc.narg(from_time)::timestamptz IS NULL OR t.created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamptz IS NULL OR t.created_at < sqlc.narg(to_time))
+  AND (sqlc.narg(after_created_at)::timestamptz IS NULL
+    OR (t.created_at, t.id) > (sqlc.narg(after_created_at)::timestamptz, sqlc.narg(after_id)::bigint))
+ORDER BY t.created_at, t.id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
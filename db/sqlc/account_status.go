@@ -0,0 +1,203 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/yuuban007/simplebank/fx"
+	"github.com/yuuban007/simplebank/util"
+)
+
+var (
+	// ErrAccountFrozen matches the AccountStatusError of a frozen account
+	ErrAccountFrozen = errors.New("account is frozen")
+	// ErrAccountClosed matches the AccountStatusError of a closed account
+	ErrAccountClosed = errors.New("account is closed")
+	// ErrInvalidStatusTransition is returned when an account can't move from its status to the requested one
+	ErrInvalidStatusTransition = errors.New("invalid account status transition")
+	// ErrAccountBalanceNotZero is returned when closing an account that still holds or owes money
+	ErrAccountBalanceNotZero = errors.New("account balance is not zero")
+	// ErrInvalidSweepAccount is returned when the balance of a closing account can't be swept to the account
+	ErrInvalidSweepAccount = errors.New("invalid sweep account")
+)
+
+// AccountStatusError is returned when money would move in or out of an account that isn't active
+type AccountStatusError struct {
+	AccountID int64
+	Status    string
+}
+
+func (err *AccountStatusError) Error() string {
+	return fmt.Sprintf("account [%d] is %s", err.AccountID, err.Status)
+}
+
+// Is lets errors.Is match the error with ErrAccountFrozen or ErrAccountClosed
+func (err *AccountStatusError) Is(target error) bool {
+	switch target {
+	case ErrAccountFrozen:
+		return err.Status == util.AccountStatusFrozen
+	case ErrAccountClosed:
+		return err.Status == util.AccountStatusClosed
+	}
+	return false
+}
+
+// checkActive makes sure money can move in and out of the account
+func checkActive(account Account) error {
+	if account.Status != util.AccountStatusActive {
+		return &AccountStatusError{AccountID: account.ID, Status: account.Status}
+	}
+	return nil
+}
+
+// accountStatusTransitions lists the statuses an account can be moved to from each status,
+// closing goes through CloseAccountTx and a closed account stays closed
+var accountStatusTransitions = map[string]string{
+	util.AccountStatusActive: util.AccountStatusFrozen,
+	util.AccountStatusFrozen: util.AccountStatusActive,
+}
+
+// UpdateAccountStatusTxParams contains the input parameters of the account status transaction
+type UpdateAccountStatusTxParams struct {
+	AccountID int64  `json:"account_id"`
+	Status    string `json:"status"`
+}
+
+// UpdateAccountStatusTx freezes an active account or unfreezes a frozen one
+func (store *SQLStore) UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusTxParams) (Account, error) {
+	var account Account
+	err := store.execTX(ctx, "update_account_status", func(ctx context.Context, q *Queries) error {
+		var err error
+		account, err = q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if account.Owner == SettlementAccountOwner {
+			return fmt.Errorf("%w: account [%d]", ErrSettlementAccount, account.ID)
+		}
+		if accountStatusTransitions[account.Status] != arg.Status {
+			return fmt.Errorf("%w: account [%d] from %s to %s", ErrInvalidStatusTransition, account.ID, account.Status, arg.Status)
+		}
+
+		account, err = q.UpdateAccountStatus(ctx, UpdateAccountStatusParams{
+			ID:     account.ID,
+			Status: arg.Status,
+		})
+		return err
+	})
+
+	return account, err
+}
+
+// CloseAccountTxParams contains the input parameters of the close account transaction
+type CloseAccountTxParams struct {
+	AccountID int64 `json:"account_id"`
+	// SweepAccountID is another account of the same owner that receives the remaining balance,
+	// without it the balance must already be zero
+	SweepAccountID int64 `json:"sweep_account_id"`
+	// ExchangeRate converts the balance to the currency of the sweep account when it differs
+	ExchangeRate string `json:"exchange_rate"`
+	// AllowFrozen lets a frozen account be closed, it is only for bank staff
+	// so that an owner can't escape a freeze by closing the account
+	AllowFrozen bool `json:"allow_frozen"`
+}
+
+// CloseAccountTxResult contains the results of the close account transaction
+type CloseAccountTxResult struct {
+	Account Account `json:"account"`
+	// Sweep is the transfer of the remaining balance, if there was one
+	Sweep *TransferTxResult `json:"sweep,omitempty"`
+}
+
+// CloseAccountTx closes an active account with a zero balance, or a frozen one when AllowFrozen is set.
+// A positive balance can be swept to another active account of the same owner first.
+func (store *SQLStore) CloseAccountTx(ctx context.Context, arg CloseAccountTxParams) (CloseAccountTxResult, error) {
+	var result CloseAccountTxResult
+	err := store.execTX(ctx, "close_account", func(ctx context.Context, q *Queries) error {
+		var account, sweepAccount Account
+		var err error
+		if arg.SweepAccountID != 0 {
+			// lock both accounts up front in the same order as a transfer between them would
+			account, sweepAccount, err = lockAccounts(ctx, q, arg.AccountID, arg.SweepAccountID)
+		} else {
+			account, err = q.GetAccountForUpdate(ctx, arg.AccountID)
+		}
+		if err != nil {
+			return err
+		}
+		if account.Owner == SettlementAccountOwner {
+			return fmt.Errorf("%w: account [%d]", ErrSettlementAccount, account.ID)
+		}
+		if account.Status == util.AccountStatusClosed {
+			return fmt.Errorf("%w: account [%d] is already closed", ErrInvalidStatusTransition, account.ID)
+		}
+		if account.Status == util.AccountStatusFrozen && !arg.AllowFrozen {
+			return &AccountStatusError{AccountID: account.ID, Status: account.Status}
+		}
+
+		if arg.SweepAccountID != 0 && account.Balance > 0 {
+			sweep, err := sweepBalance(ctx, q, account, sweepAccount, arg.ExchangeRate)
+			if err != nil {
+				return err
+			}
+			result.Sweep = &sweep
+			account = sweep.FromAccount
+		}
+
+		if account.Balance != 0 {
+			return fmt.Errorf("%w: account [%d] balance %d", ErrAccountBalanceNotZero, account.ID, account.Balance)
+		}
+
+		result.Account, err = q.UpdateAccountStatus(ctx, UpdateAccountStatusParams{
+			ID:     account.ID,
+			Status: util.AccountStatusClosed,
+		})
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if result.Sweep != nil {
+		observeTransfer(*result.Sweep)
+	}
+	return result, nil
+}
+
+// sweepBalance transfers the whole balance of the account to another account of the same owner.
+// The account itself may be frozen, its status was checked by CloseAccountTx.
+func sweepBalance(ctx context.Context, q *Queries, account Account, sweepAccount Account, exchangeRate string) (TransferTxResult, error) {
+	if sweepAccount.ID == account.ID || sweepAccount.Owner != account.Owner {
+		return TransferTxResult{}, fmt.Errorf("%w: account [%d] must be another account of %s",
+			ErrInvalidSweepAccount, sweepAccount.ID, account.Owner)
+	}
+	err := checkActive(sweepAccount)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	arg := TransferTxParams{
+		FromAccountID: account.ID,
+		ToAccountID:   sweepAccount.ID,
+		Amount:        account.Balance,
+	}
+	if sweepAccount.Currency != account.Currency {
+		rate, err := fx.ParseRate(exchangeRate)
+		if err != nil {
+			return TransferTxResult{}, fmt.Errorf("%w: %s to %s", ErrExchangeRateRequired, account.Currency, sweepAccount.Currency)
+		}
+		arg.ExchangeRate = rate.String()
+		arg.ConvertedAmount, err = rate.Convert(account.Balance)
+		if err != nil {
+			return TransferTxResult{}, err
+		}
+		if arg.ConvertedAmount <= 0 {
+			return TransferTxResult{}, fmt.Errorf("%w: balance %d %s is too small to convert to %s",
+				ErrInvalidSweepAccount, account.Balance, account.Currency, sweepAccount.Currency)
+		}
+	}
+
+	return moveMoney(ctx, q, arg, account, sweepAccount)
+}
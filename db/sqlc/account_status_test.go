@@ -0,0 +1,239 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuuban007/simplebank/util"
+)
+
+func TestUpdateAccountStatusTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+
+	frozen, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    util.AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.AccountStatusFrozen, frozen.Status)
+
+	// a frozen account can't be frozen again
+	_, err = store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    util.AccountStatusFrozen,
+	})
+	require.ErrorIs(t, err, ErrInvalidStatusTransition)
+
+	active, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    util.AccountStatusActive,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.AccountStatusActive, active.Status)
+
+	// closing goes through CloseAccountTx
+	_, err = store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    util.AccountStatusClosed,
+	})
+	require.ErrorIs(t, err, ErrInvalidStatusTransition)
+}
+
+func TestUpdateAccountStatusTxSettlementAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	settlementAccount := getSettlementAccount(t, util.RandomCurrency())
+	_, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: settlementAccount.ID,
+		Status:    util.AccountStatusFrozen,
+	})
+	require.ErrorIs(t, err, ErrSettlementAccount)
+}
+
+func TestTransferTxInactiveAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := fundAccount(t, createRandomAccountInCurrency(t, util.USD), 100)
+	account2 := createRandomAccountInCurrency(t, util.USD)
+
+	_, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account2.ID,
+		Status:    util.AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+
+	// a frozen account can neither be credited nor debited
+	for _, arg := range []TransferTxParams{
+		{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10},
+		{FromAccountID: account2.ID, ToAccountID: account1.ID, Amount: 10},
+	} {
+		_, err = store.TransferTx(context.Background(), arg)
+		require.ErrorIs(t, err, ErrAccountFrozen)
+
+		var statusErr *AccountStatusError
+		require.ErrorAs(t, err, &statusErr)
+		require.Equal(t, account2.ID, statusErr.AccountID)
+	}
+
+	_, err = store.DepositTx(context.Background(), CashTxParams{AccountID: account2.ID, Amount: 10})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	// nothing moved
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+func TestCloseAccountTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := fundAccount(t, createRandomAccountInCurrency(t, util.EUR), 0)
+	result, err := store.CloseAccountTx(context.Background(), CloseAccountTxParams{AccountID: account.ID})
+	require.NoError(t, err)
+	require.Equal(t, util.AccountStatusClosed, result.Account.Status)
+	require.Nil(t, result.Sweep)
+
+	// a closed account stays closed and takes no money
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{AccountID: account.ID})
+	require.ErrorIs(t, err, ErrInvalidStatusTransition)
+	_, err = store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    util.AccountStatusActive,
+	})
+	require.ErrorIs(t, err, ErrInvalidStatusTransition)
+
+	other := createRandomAccountInCurrency(t, util.EUR)
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: other.ID,
+		ToAccountID:   account.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrAccountClosed)
+
+	// the owner can open a new account in the same currency
+	_, err = store.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    account.Owner,
+		Balance:  0,
+		Currency: account.Currency,
+	})
+	require.NoError(t, err)
+}
+
+func TestCloseAccountTxBalance(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := fundAccount(t, createRandomAccountInCurrency(t, util.CAD), 100)
+	sweepAccount := fundAccount(t, createRandomAccountOfOwner(t, account.Owner, util.USD), 0)
+	otherOwnerAccount := createRandomAccountInCurrency(t, util.CAD)
+
+	_, err := store.CloseAccountTx(context.Background(), CloseAccountTxParams{AccountID: account.ID})
+	require.ErrorIs(t, err, ErrAccountBalanceNotZero)
+
+	// the balance can't be swept to the account of someone else
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:      account.ID,
+		SweepAccountID: otherOwnerAccount.ID,
+	})
+	require.ErrorIs(t, err, ErrInvalidSweepAccount)
+
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:      account.ID,
+		SweepAccountID: sweepAccount.ID,
+	})
+	require.ErrorIs(t, err, ErrExchangeRateRequired)
+
+	result, err := store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:      account.ID,
+		SweepAccountID: sweepAccount.ID,
+		ExchangeRate:   "0.73",
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.AccountStatusClosed, result.Account.Status)
+	require.Zero(t, result.Account.Balance)
+
+	require.NotNil(t, result.Sweep)
+	require.Equal(t, int64(100), result.Sweep.Transfer.Amount)
+	require.Equal(t, int64(73), result.Sweep.Transfer.ConvertedAmount)
+	require.Equal(t, "0.73", result.Sweep.Transfer.ExchangeRate)
+	require.Equal(t, sweepAccount.ID, result.Sweep.ToAccount.ID)
+	require.Equal(t, int64(73), result.Sweep.ToAccount.Balance)
+
+	// an overdrawn account can't be swept
+	overdrawn := fundAccount(t, createRandomAccountInCurrency(t, util.CAD), -50)
+	overdrawnSweepAccount := createRandomAccountOfOwner(t, overdrawn.Owner, util.EUR)
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:      overdrawn.ID,
+		SweepAccountID: overdrawnSweepAccount.ID,
+		ExchangeRate:   "0.68",
+	})
+	require.ErrorIs(t, err, ErrAccountBalanceNotZero)
+}
+
+func TestCloseAccountTxFrozen(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := fundAccount(t, createRandomAccountInCurrency(t, util.USD), 0)
+	_, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    util.AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+
+	// the owner can't escape a freeze by closing the account
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{AccountID: account.ID})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	result, err := store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:   account.ID,
+		AllowFrozen: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.AccountStatusClosed, result.Account.Status)
+}
+
+func TestCloseAccountTxFrozenBalance(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := fundAccount(t, createRandomAccountInCurrency(t, util.EUR), 100)
+	sweepAccount := fundAccount(t, createRandomAccountOfOwner(t, account.Owner, util.USD), 0)
+	_, err := store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    util.AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+
+	arg := CloseAccountTxParams{
+		AccountID:      account.ID,
+		SweepAccountID: sweepAccount.ID,
+		ExchangeRate:   "1.08",
+	}
+	_, err = store.CloseAccountTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	// bank staff can sweep the balance out of the frozen account to close it
+	arg.AllowFrozen = true
+	result, err := store.CloseAccountTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, util.AccountStatusClosed, result.Account.Status)
+	require.Zero(t, result.Account.Balance)
+	require.NotNil(t, result.Sweep)
+	require.Equal(t, int64(108), result.Sweep.ToAccount.Balance)
+
+	// the sweep account must still be active
+	account = fundAccount(t, createRandomAccountInCurrency(t, util.EUR), 100)
+	sweepAccount = createRandomAccountOfOwner(t, account.Owner, util.USD)
+	_, err = store.UpdateAccountStatusTx(context.Background(), UpdateAccountStatusTxParams{
+		AccountID: sweepAccount.ID,
+		Status:    util.AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		AccountID:      account.ID,
+		SweepAccountID: sweepAccount.ID,
+		ExchangeRate:   "1.08",
+		AllowFrozen:    true,
+	})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+}
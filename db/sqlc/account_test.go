@@ -2,7 +2,6 @@ package db
 
 import (
 	"context"
-	"database/sql"
 	"testing"
 	"time"
 
@@ -11,11 +10,19 @@ import (
 )
 
 func createRandomAccount(t *testing.T) Account {
+	return createRandomAccountInCurrency(t, util.RandomCurrency())
+}
+
+func createRandomAccountInCurrency(t *testing.T, currency string) Account {
 	user := createRandomUser(t)
+	return createRandomAccountOfOwner(t, user.Username, currency)
+}
+
+func createRandomAccountOfOwner(t *testing.T, owner string, currency string) Account {
 	arg := CreateAccountParams{
-		Owner:    user.Username,
+		Owner:    owner,
 		Balance:  util.RandomMoney(),
-		Currency: util.RandomCurrency(),
+		Currency: currency,
 	}
 
 	account, err := testStore.CreateAccount(context.Background(), arg)
@@ -25,12 +32,24 @@ func createRandomAccount(t *testing.T) Account {
 	require.Equal(t, arg.Balance, account.Balance)
 	require.Equal(t, arg.Owner, account.Owner)
 	require.Equal(t, arg.Currency, account.Currency)
+	require.Equal(t, util.AccountStatusActive, account.Status)
 
 	require.NotZero(t, account.ID)
 	require.NotZero(t, account.CreatedAt)
 
 	return account
 }
+
+// fundAccount sets the balance of the account so transfer tests don't depend on its random balance
+func fundAccount(t *testing.T, account Account, balance int64) Account {
+	account, err := testStore.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account.ID,
+		Balance: balance,
+	})
+	require.NoError(t, err)
+	return account
+}
+
 func TestCreateAccount(t *testing.T) {
 	createRandomAccount(t)
 }
@@ -64,6 +83,38 @@ func TestUpdateAccount(t *testing.T) {
 	require.Equal(t, account1.Owner, account2.Owner)
 	require.WithinDuration(t, account1.CreatedAt, account2.CreatedAt, time.Second)
 }
+func TestUpdateAccountStatus(t *testing.T) {
+	account1 := createRandomAccount(t)
+
+	arg := UpdateAccountStatusParams{
+		ID:     account1.ID,
+		Status: util.AccountStatusFrozen,
+	}
+	account2, err := testStore.UpdateAccountStatus(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, account2)
+
+	require.Equal(t, arg.ID, account2.ID)
+	require.Equal(t, arg.Status, account2.Status)
+	require.Equal(t, account1.Balance, account2.Balance)
+	require.Equal(t, account1.Owner, account2.Owner)
+}
+func TestUpdateAccountOverdraftLimit(t *testing.T) {
+	account1 := createRandomAccount(t)
+	require.Zero(t, account1.OverdraftLimit)
+
+	arg := UpdateAccountOverdraftLimitParams{
+		ID:             account1.ID,
+		OverdraftLimit: util.RandomMoney(),
+	}
+	account2, err := testStore.UpdateAccountOverdraftLimit(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, account2)
+
+	require.Equal(t, arg.ID, account2.ID)
+	require.Equal(t, arg.OverdraftLimit, account2.OverdraftLimit)
+	require.Equal(t, account1.Balance, account2.Balance)
+}
 func TestDeleteAccount(t *testing.T) {
 	account1 := createRandomAccount(t)
 
@@ -73,7 +124,7 @@ func TestDeleteAccount(t *testing.T) {
 	account2, err2 := testStore.GetAccount(context.Background(), account1.ID)
 	require.Error(t, err2)
 	require.Empty(t, account2)
-	require.EqualError(t, err2, sql.ErrNoRows.Error())
+	require.ErrorIs(t, err2, ErrRecordNotFound)
 }
 func TestListAccount(t *testing.T) {
 	var lastAccount Account
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// SettlementAccountOwner owns the settlement accounts, one per currency,
+// that balance the entries of cash deposits and withdrawals
+const SettlementAccountOwner = "simplebank"
+
+// ErrSettlementAccount is returned when cash is deposited to or withdrawn from a settlement account itself,
+// or when a settlement account is frozen, unfrozen or closed
+var ErrSettlementAccount = errors.New("operation not allowed on a settlement account")
+
+// CashTxParams contains the input parameters of deposit and withdrawal transactions
+type CashTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+}
+
+// CashTxResult contains the results of deposit and withdrawal transactions
+type CashTxResult struct {
+	Account           Account `json:"account"`
+	SettlementAccount Account `json:"settlement_account"`
+	Entry             Entry   `json:"entry"`
+	SettlementEntry   Entry   `json:"settlement_entry"`
+}
+
+// DepositTx adds cash to an account, balanced by an entry of the settlement account of its currency
+func (store *SQLStore) DepositTx(ctx context.Context, arg CashTxParams) (CashTxResult, error) {
+	var result CashTxResult
+	err := store.execTX(ctx, "deposit", func(ctx context.Context, q *Queries) error {
+		var err error
+		result, err = moveCash(ctx, q, arg.AccountID, arg.Amount)
+		return err
+	})
+
+	return result, err
+}
+
+// WithdrawTx takes cash out of an account, balanced by an entry of the settlement account of its currency.
+// Like a transfer it can't take the account below its overdraft limit.
+func (store *SQLStore) WithdrawTx(ctx context.Context, arg CashTxParams) (CashTxResult, error) {
+	var result CashTxResult
+	err := store.execTX(ctx, "withdraw", func(ctx context.Context, q *Queries) error {
+		var err error
+		result, err = moveCash(ctx, q, arg.AccountID, -arg.Amount)
+		return err
+	})
+
+	return result, err
+}
+
+// moveCash adds the amount to the account and the opposite amount to the settlement account
+func moveCash(ctx context.Context, q *Queries, accountID int64, amount int64) (CashTxResult, error) {
+	var result CashTxResult
+
+	account, err := q.GetAccount(ctx, accountID)
+	if err != nil {
+		return result, err
+	}
+	if account.Owner == SettlementAccountOwner {
+		return result, fmt.Errorf("%w: account [%d]", ErrSettlementAccount, account.ID)
+	}
+
+	settlementAccount, err := q.GetAccountByCurrency(ctx, GetAccountByCurrencyParams{
+		Owner:    SettlementAccountOwner,
+		Currency: account.Currency,
+	})
+	if err != nil {
+		return result, fmt.Errorf("cannot get settlement account of %s: %w", account.Currency, err)
+	}
+
+	account, _, err = lockAccounts(ctx, q, account.ID, settlementAccount.ID)
+	if err != nil {
+		return result, err
+	}
+	err = checkActive(account)
+	if err != nil {
+		return result, err
+	}
+	if amount < 0 {
+		err = checkFunds(account, -amount)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: account.ID,
+		Amount:    amount,
+	})
+	if err != nil {
+		return result, err
+	}
+	result.SettlementEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: settlementAccount.ID,
+		Amount:    -amount,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if account.ID < settlementAccount.ID {
+		result.Account, result.SettlementAccount, err = addMoney(ctx, q, account.ID, amount, settlementAccount.ID, -amount)
+	} else {
+		result.SettlementAccount, result.Account, err = addMoney(ctx, q, settlementAccount.ID, -amount, account.ID, amount)
+	}
+	return result, err
+}
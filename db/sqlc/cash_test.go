@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuuban007/simplebank/util"
+)
+
+func getSettlementAccount(t *testing.T, currency string) Account {
+	account, err := testStore.GetAccountByCurrency(context.Background(), GetAccountByCurrencyParams{
+		Owner:    SettlementAccountOwner,
+		Currency: currency,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func TestDepositTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccount(t)
+	amount := util.RandomMoney() + 1
+
+	result, err := store.DepositTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    amount,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, account.ID, result.Account.ID)
+	require.Equal(t, account.Balance+amount, result.Account.Balance)
+	require.Equal(t, account.ID, result.Entry.AccountID)
+	require.Equal(t, amount, result.Entry.Amount)
+
+	// the settlement account of the currency balances the entry
+	require.Equal(t, SettlementAccountOwner, result.SettlementAccount.Owner)
+	require.Equal(t, account.Currency, result.SettlementAccount.Currency)
+	require.Equal(t, result.SettlementAccount.ID, result.SettlementEntry.AccountID)
+	require.Equal(t, -amount, result.SettlementEntry.Amount)
+
+	_, err = store.GetEntryById(context.Background(), result.Entry.ID)
+	require.NoError(t, err)
+	_, err = store.GetEntryById(context.Background(), result.SettlementEntry.ID)
+	require.NoError(t, err)
+}
+
+func TestWithdrawTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	amount := int64(10)
+	account := fundAccount(t, createRandomAccount(t), amount)
+
+	result, err := store.WithdrawTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    amount,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, int64(0), result.Account.Balance)
+	require.Equal(t, -amount, result.Entry.Amount)
+	require.Equal(t, amount, result.SettlementEntry.Amount)
+
+	// the account can't go below its overdraft limit
+	_, err = store.WithdrawTx(context.Background(), CashTxParams{
+		AccountID: account.ID,
+		Amount:    1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestCashTxSettlementAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	settlementAccount := getSettlementAccount(t, util.RandomCurrency())
+	_, err := store.DepositTx(context.Background(), CashTxParams{
+		AccountID: settlementAccount.ID,
+		Amount:    10,
+	})
+	require.ErrorIs(t, err, ErrSettlementAccount)
+}
+
+func TestSettlementUserHasNoPrivileges(t *testing.T) {
+	user, err := testStore.GetUser(context.Background(), SettlementAccountOwner)
+	require.NoError(t, err)
+	require.Equal(t, util.ServiceRole, user.Role)
+	require.False(t, util.IsStaffRole(user.Role))
+}
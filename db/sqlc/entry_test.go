@@ -5,6 +5,7 @@ import (
 	"math/rand"
 	"testing"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 )
 
@@ -48,6 +49,36 @@ func TestListEntry(t *testing.T) {
 	}
 }
 
+func TestListEntryAfterCursor(t *testing.T) {
+	account := createRandomAccount(t)
+	for i := 0; i < 3; i++ {
+		_, err := testStore.CreateEntry(context.Background(), CreateEntryParams{
+			AccountID: account.ID,
+			Amount:    int64(i + 1),
+		})
+		require.NoError(t, err)
+	}
+
+	page1, err := testStore.ListEntry(context.Background(), ListEntryParams{
+		AccountID: account.ID,
+		Limit:     2,
+	})
+	require.NoError(t, err)
+	require.Len(t, page1, 2)
+
+	last := page1[len(page1)-1]
+	page2, err := testStore.ListEntry(context.Background(), ListEntryParams{
+		AccountID:      account.ID,
+		AfterCreatedAt: pgtype.Timestamptz{Time: last.CreatedAt, Valid: true},
+		AfterID:        pgtype.Int8{Int64: last.ID, Valid: true},
+		Limit:          2,
+	})
+	require.NoError(t, err)
+	require.Len(t, page2, 1)
+	require.Greater(t, page2[0].ID, last.ID)
+	require.Equal(t, int64(3), page2[0].Amount)
+}
+
 func TestGetEntryById(t *testing.T) {
 	entry1 := createRandomEntry(t)
 
@@ -0,0 +1,16 @@
+package db
+
+import "context"
+
+// Ping checks that postgres can be reached through the connection pool
+func (store *SQLStore) Ping(ctx context.Context) error {
+	return store.connPool.Ping(ctx)
+}
+
+// MigrationVersion returns the version of the last migration applied by golang-migrate,
+// dirty is true when that migration failed halfway and the schema needs fixing by hand
+func (store *SQLStore) MigrationVersion(ctx context.Context) (version int64, dirty bool, err error) {
+	// schema_migrations belongs to golang-migrate and isn't part of the sqlc schema
+	err = store.connPool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	return
+}
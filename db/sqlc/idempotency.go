@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrIdempotencyKeyReused is returned when an idempotency key is reused with a different request
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used with a different request")
+
+// IdempotentTransferTxParams contains the input parameters of idempotent transfer transaction
+type IdempotentTransferTxParams struct {
+	TransferTxParams
+	Username    string    `json:"username"`
+	Key         string    `json:"key"`
+	RequestHash string    `json:"request_hash"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// IdempotentTransferTxResult contains the results of idempotent transfer transaction
+type IdempotentTransferTxResult struct {
+	TransferTxResult
+	// Replayed is true when the result is the stored result of a previous request with the same key
+	Replayed bool `json:"-"`
+}
+
+// IdempotentTransferTx performs a transfer at most once per idempotency key of the user.
+// A retry with the same key and request gets the result of the first transfer back,
+// a retry with the same key but a different request fails with ErrIdempotencyKeyReused.
+func (store *SQLStore) IdempotentTransferTx(ctx context.Context, arg IdempotentTransferTxParams) (IdempotentTransferTxResult, error) {
+	var result IdempotentTransferTxResult
+	err := store.execTX(ctx, "idempotent_transfer", func(ctx context.Context, q *Queries) error {
+		// expired keys can be used again
+		err := q.DeleteExpiredIdempotencyKeys(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		// the primary key makes a concurrent request with the same key wait here until this transaction ends
+		_, err = q.CreateIdempotencyKey(ctx, CreateIdempotencyKeyParams{
+			Username:    arg.Username,
+			Key:         arg.Key,
+			RequestHash: arg.RequestHash,
+			ExpiresAt:   arg.ExpiresAt,
+		})
+		if errors.Is(err, ErrRecordNotFound) {
+			return replayIdempotencyKey(ctx, q, arg, &result)
+		}
+		if err != nil {
+			return err
+		}
+
+		result.TransferTxResult, err = transfer(ctx, q, arg.TransferTxParams)
+		if err != nil {
+			return err
+		}
+
+		response, err := json.Marshal(result.TransferTxResult)
+		if err != nil {
+			return err
+		}
+		_, err = q.UpdateIdempotencyKeyResponse(ctx, UpdateIdempotencyKeyResponseParams{
+			Username: arg.Username,
+			Key:      arg.Key,
+			Response: response,
+		})
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// a replay moved no money
+	if !result.Replayed {
+		observeTransfer(result.TransferTxResult)
+	}
+	return result, nil
+}
+
+// replayIdempotencyKey loads the result stored for a key that was already used
+func replayIdempotencyKey(ctx context.Context, q *Queries, arg IdempotentTransferTxParams, result *IdempotentTransferTxResult) error {
+	idempotencyKey, err := q.GetIdempotencyKey(ctx, GetIdempotencyKeyParams{
+		Username: arg.Username,
+		Key:      arg.Key,
+	})
+	if err != nil {
+		return err
+	}
+
+	if idempotencyKey.RequestHash != arg.RequestHash {
+		return ErrIdempotencyKeyReused
+	}
+
+	result.Replayed = true
+	return json.Unmarshal(idempotencyKey.Response, &result.TransferTxResult)
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics of the store, labelled with the name execTX is given, e.g. transfer or deposit
+var (
+	txDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "simplebank",
+		Subsystem: "db",
+		Name:      "transaction_duration_seconds",
+		Help:      "Duration of the database transactions, from begin to commit or rollback.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"tx"})
+
+	txRollbacks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "simplebank",
+		Subsystem: "db",
+		Name:      "transaction_rollbacks_total",
+		Help:      "Number of database transactions rolled back because of an error.",
+	}, []string{"tx"})
+
+	transfersTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "simplebank",
+		Name:      "transfers_total",
+		Help:      "Number of committed transfers by the currency of the from account.",
+	}, []string{"currency"})
+
+	transferredAmount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "simplebank",
+		Name:      "transferred_amount_total",
+		Help:      "Amount taken from the from accounts of committed transfers, in the minor unit of their currency.",
+	}, []string{"currency"})
+)
+
+// observeTransfer records a transfer once its transaction is committed
+func observeTransfer(result TransferTxResult) {
+	currency := result.FromAccount.Currency
+	transfersTotal.WithLabelValues(currency).Inc()
+	transferredAmount.WithLabelValues(currency).Add(float64(result.Transfer.Amount))
+}
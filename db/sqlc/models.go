@@ -5,7 +5,10 @@
 package db
 
 import (
+	"encoding/json"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // bank account
@@ -16,6 +19,10 @@ type Account struct {
 	// like USD or RMB
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	// active, frozen or closed
+	Status string `json:"status"`
+	// how far below zero the balance may go
+	OverdraftLimit int64 `json:"overdraft_limit"`
 }
 
 // record all the account change
@@ -25,15 +32,58 @@ type Entry struct {
 	// could be positive or negative
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// the transfer that created the entry, null for cash deposits and withdrawals
+	TransferID *int64 `json:"transfer_id"`
+}
+
+type IdempotencyKey struct {
+	Username string `json:"username"`
+	Key      string `json:"key"`
+	// sha256 of the request the key was first used with
+	RequestHash string `json:"request_hash"`
+	// serialized result replayed to retries
+	Response  json.RawMessage `json:"response"`
+	ExpiresAt time.Time       `json:"expires_at"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type RateLimit struct {
+	// what is limited, e.g. ip:203.0.113.7 or login:alice
+	Key  string `json:"key"`
+	Hits int64  `json:"hits"`
+	// end of the current window, the hits count again from zero after it
+	ResetAt time.Time `json:"reset_at"`
+}
+
+type RevokedToken struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	ExpiresAt time.Time `json:"expires_at"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+type Session struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 type Transfer struct {
 	ID            int64 `json:"id"`
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
-	// must be positive
+	// must be positive, in the currency of the from account
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// amount in the currency of the to account
+	ConvertedAmount int64 `json:"converted_amount"`
+	// rate applied to convert amount into converted_amount
+	ExchangeRate string `json:"exchange_rate"`
 }
 
 type User struct {
@@ -43,4 +93,12 @@ type User struct {
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	// depositor, banker or admin
+	Role string `json:"role"`
+}
+
+type UserTokenRevocation struct {
+	Username string `json:"username"`
+	// tokens issued before this time are revoked
+	RevokedBefore time.Time `json:"revoked_before"`
 }
@@ -6,24 +6,52 @@ package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	BlockSession(ctx context.Context, id uuid.UUID) error
+	BlockUserSessions(ctx context.Context, username string) error
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error)
+	CreateRevokedToken(ctx context.Context, arg CreateRevokedTokenParams) (RevokedToken, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, username string) error
+	DeleteExpiredRateLimits(ctx context.Context, resetAt time.Time) error
+	DeleteExpiredRevokedTokens(ctx context.Context, username string) error
+	DeleteRateLimit(ctx context.Context, key string) error
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountByCurrency(ctx context.Context, arg GetAccountByCurrencyParams) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
 	GetEntryById(ctx context.Context, id int64) (Entry, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	IncrementRateLimit(ctx context.Context, arg IncrementRateLimitParams) (RateLimit, error)
+	IsTokenRevoked(ctx context.Context, arg IsTokenRevokedParams) (bool, error)
+	// after_created_at and after_id are the keyset cursor of the last account of the previous page.
 	ListAccount(ctx context.Context, arg ListAccountParams) ([]Account, error)
+	// after_created_at and after_id are the keyset cursor of the last entry of the previous page.
 	ListEntry(ctx context.Context, arg ListEntryParams) ([]Entry, error)
+	ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error)
+	// every filter is optional, direction is 'in' or 'out' relative to the owner and the account.
+	// amount and currency are those of the from account, the currency the transfer was made in.
+	// after_created_at and after_id are the keyset cursor of the last transfer of the previous page.
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	RevokeUserTokens(ctx context.Context, arg RevokeUserTokensParams) (UserTokenRevocation, error)
+	SumEntriesSince(ctx context.Context, arg SumEntriesSinceParams) (int64, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error)
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
+	UpdateIdempotencyKeyResponse(ctx context.Context, arg UpdateIdempotencyKeyResponseParams) (IdempotencyKey, error)
 }
 
 var _ Querier = (*Queries)(nil)
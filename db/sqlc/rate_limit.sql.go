@@ -0,0 +1,58 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.25.0
+// source: rate_limit.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const deleteExpiredRateLimits = `-- name: DeleteExpiredRateLimits :exec
+DELETE FROM rate_limits
+WHERE reset_at <= $1
+`
+
+func (q *Queries) DeleteExpiredRateLimits(ctx context.Context, resetAt time.Time) error {
+	_, err := q.db.Exec(ctx, deleteExpiredRateLimits, resetAt)
+	return err
+}
+
+const deleteRateLimit = `-- name: DeleteRateLimit :exec
+DELETE FROM rate_limits
+WHERE key = $1
+`
+
+func (q *Queries) DeleteRateLimit(ctx context.Context, key string) error {
+	_, err := q.db.Exec(ctx, deleteRateLimit, key)
+	return err
+}
+
+const incrementRateLimit = `-- name: IncrementRateLimit :one
+INSERT INTO rate_limits (
+  key,
+  hits,
+  reset_at
+) VALUES (
+  $1, 1, $2
+)
+ON CONFLICT (key) DO UPDATE
+SET hits = CASE WHEN rate_limits.reset_at <= $3 THEN 1 ELSE rate_limits.hits + 1 END,
+    reset_at = CASE WHEN rate_limits.reset_at <= $3 THEN EXCLUDED.reset_at ELSE rate_limits.reset_at END
+RETURNING key, hits, reset_at
+`
+
+type IncrementRateLimitParams struct {
+	Key     string    `json:"key"`
+	ResetAt time.Time `json:"reset_at"`
+	Now     time.Time `json:"now"`
+}
+
+func (q *Queries) IncrementRateLimit(ctx context.Context, arg IncrementRateLimitParams) (RateLimit, error) {
+	row := q.db.QueryRow(ctx, incrementRateLimit, arg.Key, arg.ResetAt, arg.Now)
+	var i RateLimit
+	err := row.Scan(&i.Key, &i.Hits, &i.ResetAt)
+	return i, err
+}
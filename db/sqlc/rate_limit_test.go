@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuuban007/simplebank/util"
+)
+
+// getRateLimit reads the window of a key, the server itself only ever increments and deletes them
+func getRateLimit(key string) (RateLimit, error) {
+	var rateLimit RateLimit
+	err := testDB.QueryRow(context.Background(), "SELECT key, hits, reset_at FROM rate_limits WHERE key = $1", key).
+		Scan(&rateLimit.Key, &rateLimit.Hits, &rateLimit.ResetAt)
+	return rateLimit, err
+}
+
+func TestIncrementRateLimit(t *testing.T) {
+	key := util.RandomString(12)
+	now := time.Now()
+
+	arg := IncrementRateLimitParams{
+		Key:     key,
+		ResetAt: now.Add(time.Minute),
+		Now:     now,
+	}
+	rateLimit1, err := testStore.IncrementRateLimit(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rateLimit1.Hits)
+	require.WithinDuration(t, arg.ResetAt, rateLimit1.ResetAt, time.Second)
+
+	// a hit within the window keeps its end
+	arg.ResetAt = now.Add(2 * time.Minute)
+	rateLimit2, err := testStore.IncrementRateLimit(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), rateLimit2.Hits)
+	require.Equal(t, rateLimit1.ResetAt, rateLimit2.ResetAt)
+
+	// the first hit after the window ended starts a new one
+	arg.Now = now.Add(time.Minute)
+	rateLimit3, err := testStore.IncrementRateLimit(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rateLimit3.Hits)
+	require.WithinDuration(t, arg.ResetAt, rateLimit3.ResetAt, time.Second)
+
+	rateLimit4, err := getRateLimit(key)
+	require.NoError(t, err)
+	require.Equal(t, rateLimit3, rateLimit4)
+}
+
+func TestDeleteRateLimit(t *testing.T) {
+	key := util.RandomString(12)
+	_, err := testStore.IncrementRateLimit(context.Background(), IncrementRateLimitParams{
+		Key:     key,
+		ResetAt: time.Now().Add(time.Minute),
+		Now:     time.Now(),
+	})
+	require.NoError(t, err)
+
+	err = testStore.DeleteRateLimit(context.Background(), key)
+	require.NoError(t, err)
+
+	_, err = getRateLimit(key)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestDeleteExpiredRateLimits(t *testing.T) {
+	expiredKey := util.RandomString(12)
+	activeKey := util.RandomString(12)
+	now := time.Now()
+
+	for key, resetAt := range map[string]time.Time{
+		expiredKey: now.Add(-time.Second),
+		activeKey:  now.Add(time.Minute),
+	} {
+		_, err := testStore.IncrementRateLimit(context.Background(), IncrementRateLimitParams{
+			Key:     key,
+			ResetAt: resetAt,
+			Now:     now,
+		})
+		require.NoError(t, err)
+	}
+
+	err := testStore.DeleteExpiredRateLimits(context.Background(), now)
+	require.NoError(t, err)
+
+	_, err = getRateLimit(expiredKey)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+	_, err = getRateLimit(activeKey)
+	require.NoError(t, err)
+}
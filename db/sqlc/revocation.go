@@ -0,0 +1,153 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// revocationCacheTTL is how long a revocation lookup is trusted before asking postgres again.
+// Revocations made through this store are applied to the cache immediately,
+// so the ttl only bounds how long revocations made by other instances may go unnoticed.
+const revocationCacheTTL = 30 * time.Second
+
+// revocationCacheMaxSize bounds the cache, expired entries are swept when it is reached
+const revocationCacheMaxSize = 10000
+
+type revocationCacheEntry struct {
+	username  string
+	revoked   bool
+	expiresAt time.Time
+}
+
+// revocationCache caches token revocation lookups keyed by token id
+type revocationCache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[uuid.UUID]revocationCacheEntry
+}
+
+func newRevocationCache(ttl time.Duration) *revocationCache {
+	return &revocationCache{
+		ttl:     ttl,
+		entries: make(map[uuid.UUID]revocationCacheEntry),
+	}
+}
+
+func (cache *revocationCache) get(id uuid.UUID) (revoked bool, ok bool) {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	entry, ok := cache.entries[id]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return false, false
+	}
+	return entry.revoked, true
+}
+
+func (cache *revocationCache) set(id uuid.UUID, username string, revoked bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	now := time.Now()
+	if len(cache.entries) >= revocationCacheMaxSize {
+		for key, entry := range cache.entries {
+			if now.After(entry.expiresAt) {
+				delete(cache.entries, key)
+			}
+		}
+	}
+	cache.entries[id] = revocationCacheEntry{
+		username:  username,
+		revoked:   revoked,
+		expiresAt: now.Add(cache.ttl),
+	}
+}
+
+// forgetUser drops every cached lookup of the user so the next lookup sees the revocation
+func (cache *revocationCache) forgetUser(username string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	for key, entry := range cache.entries {
+		if entry.username == username {
+			delete(cache.entries, key)
+		}
+	}
+}
+
+// IsTokenRevoked reports whether the token was revoked by id or by a logout of all the user's tokens.
+// Lookups are cached so that authenticated requests don't hit the database every time.
+func (store *SQLStore) IsTokenRevoked(ctx context.Context, arg IsTokenRevokedParams) (bool, error) {
+	if revoked, ok := store.revocations.get(arg.ID); ok {
+		return revoked, nil
+	}
+
+	revoked, err := store.Queries.IsTokenRevoked(ctx, arg)
+	if err != nil {
+		return false, err
+	}
+	store.revocations.set(arg.ID, arg.Username, revoked)
+	return revoked, nil
+}
+
+// LogoutTxParams contains the input parameters of logout transaction
+type LogoutTxParams struct {
+	Username  string        `json:"username"`
+	TokenID   uuid.UUID     `json:"token_id"`
+	ExpiresAt time.Time     `json:"expires_at"`
+	SessionID uuid.NullUUID `json:"session_id"`
+}
+
+// LogoutTx revokes a single access token and blocks its session if one is given
+func (store *SQLStore) LogoutTx(ctx context.Context, arg LogoutTxParams) error {
+	err := store.execTX(ctx, "logout", func(ctx context.Context, q *Queries) error {
+		_, err := q.CreateRevokedToken(ctx, CreateRevokedTokenParams{
+			ID:        arg.TokenID,
+			Username:  arg.Username,
+			ExpiresAt: arg.ExpiresAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		if arg.SessionID.Valid {
+			err = q.BlockSession(ctx, arg.SessionID.UUID)
+			if err != nil {
+				return err
+			}
+		}
+
+		// expired tokens can't be used anyway, so there is no need to keep them
+		return q.DeleteExpiredRevokedTokens(ctx, arg.Username)
+	})
+	if err != nil {
+		return err
+	}
+
+	store.revocations.set(arg.TokenID, arg.Username, true)
+	return nil
+}
+
+// LogoutAllTx revokes every token issued to the user so far and blocks all of the user's sessions
+func (store *SQLStore) LogoutAllTx(ctx context.Context, username string) error {
+	err := store.execTX(ctx, "logout_all", func(ctx context.Context, q *Queries) error {
+		_, err := q.RevokeUserTokens(ctx, RevokeUserTokensParams{
+			Username:      username,
+			RevokedBefore: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+
+		return q.BlockUserSessions(ctx, username)
+	})
+	if err != nil {
+		return err
+	}
+
+	store.revocations.forgetUser(username)
+	return nil
+}
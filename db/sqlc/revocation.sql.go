@@ -0,0 +1,104 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.25.0
+// source: revocation.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createRevokedToken = `-- name: CreateRevokedToken :one
+INSERT INTO revoked_tokens (
+  id,
+  username,
+  expires_at
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (id) DO UPDATE
+SET expires_at = EXCLUDED.expires_at
+RETURNING id, username, expires_at, revoked_at
+`
+
+type CreateRevokedTokenParams struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateRevokedToken(ctx context.Context, arg CreateRevokedTokenParams) (RevokedToken, error) {
+	row := q.db.QueryRow(ctx, createRevokedToken, arg.ID, arg.Username, arg.ExpiresAt)
+	var i RevokedToken
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const deleteExpiredRevokedTokens = `-- name: DeleteExpiredRevokedTokens :exec
+DELETE FROM revoked_tokens
+WHERE username = $1 AND expires_at < now()
+`
+
+func (q *Queries) DeleteExpiredRevokedTokens(ctx context.Context, username string) error {
+	_, err := q.db.Exec(ctx, deleteExpiredRevokedTokens, username)
+	return err
+}
+
+const isTokenRevoked = `-- name: IsTokenRevoked :one
+SELECT (
+  EXISTS (
+    SELECT 1 FROM revoked_tokens
+    WHERE revoked_tokens.id = $1
+  ) OR EXISTS (
+    SELECT 1 FROM user_token_revocations
+    WHERE user_token_revocations.username = $2
+      AND user_token_revocations.revoked_before >= $3
+  )
+)::boolean AS revoked
+`
+
+type IsTokenRevokedParams struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	IssuedAt time.Time `json:"issued_at"`
+}
+
+func (q *Queries) IsTokenRevoked(ctx context.Context, arg IsTokenRevokedParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isTokenRevoked, arg.ID, arg.Username, arg.IssuedAt)
+	var revoked bool
+	err := row.Scan(&revoked)
+	return revoked, err
+}
+
+const revokeUserTokens = `-- name: RevokeUserTokens :one
+INSERT INTO user_token_revocations (
+  username,
+  revoked_before
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (username) DO UPDATE
+SET revoked_before = EXCLUDED.revoked_before
+RETURNING username, revoked_before
+`
+
+type RevokeUserTokensParams struct {
+	Username      string    `json:"username"`
+	RevokedBefore time.Time `json:"revoked_before"`
+}
+
+func (q *Queries) RevokeUserTokens(ctx context.Context, arg RevokeUserTokensParams) (UserTokenRevocation, error) {
+	row := q.db.QueryRow(ctx, revokeUserTokens, arg.Username, arg.RevokedBefore)
+	var i UserTokenRevocation
+	err := row.Scan(&i.Username, &i.RevokedBefore)
+	return i, err
+}
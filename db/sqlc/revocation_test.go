@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogoutTx(t *testing.T) {
+	session := createRandomSession(t)
+	tokenID := uuid.New()
+	issuedAt := time.Now()
+
+	arg := IsTokenRevokedParams{
+		ID:       tokenID,
+		Username: session.Username,
+		IssuedAt: issuedAt,
+	}
+	revoked, err := testStore.IsTokenRevoked(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	err = testStore.LogoutTx(context.Background(), LogoutTxParams{
+		Username:  session.Username,
+		TokenID:   tokenID,
+		ExpiresAt: issuedAt.Add(time.Minute),
+		SessionID: uuid.NullUUID{UUID: session.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	revoked, err = testStore.IsTokenRevoked(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	session2, err := testStore.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session2.IsBlocked)
+}
+
+func TestLogoutAllTx(t *testing.T) {
+	session := createRandomSession(t)
+
+	arg := IsTokenRevokedParams{
+		ID:       uuid.New(),
+		Username: session.Username,
+		IssuedAt: time.Now(),
+	}
+	revoked, err := testStore.IsTokenRevoked(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	err = testStore.LogoutAllTx(context.Background(), session.Username)
+	require.NoError(t, err)
+
+	revoked, err = testStore.IsTokenRevoked(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	// tokens issued after the logout are still valid
+	arg.ID = uuid.New()
+	arg.IssuedAt = time.Now().Add(time.Second)
+	revoked, err = testStore.IsTokenRevoked(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	session2, err := testStore.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, session2.IsBlocked)
+}
+
+func TestRevocationCache(t *testing.T) {
+	cache := newRevocationCache(time.Minute)
+	id := uuid.New()
+
+	_, ok := cache.get(id)
+	require.False(t, ok)
+
+	cache.set(id, "user", false)
+	revoked, ok := cache.get(id)
+	require.True(t, ok)
+	require.False(t, revoked)
+
+	cache.forgetUser("user")
+	_, ok = cache.get(id)
+	require.False(t, ok)
+
+	expired := newRevocationCache(-time.Second)
+	expired.set(id, "user", true)
+	_, ok = expired.get(id)
+	require.False(t, ok)
+}
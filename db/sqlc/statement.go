@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// AccountStatementTxParams contains the input parameters of account statement transaction
+type AccountStatementTxParams struct {
+	AccountID int64     `json:"account_id"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+}
+
+// StatementHeader is what is known of a statement before its lines are read
+type StatementHeader struct {
+	Account        Account   `json:"account"`
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	OpeningBalance int64     `json:"opening_balance"`
+}
+
+// StatementLine is an entry of the statement with the balance of the account right after it
+type StatementLine struct {
+	ListStatementEntriesRow
+	Balance int64 `json:"balance"`
+}
+
+// StatementWriter receives an account statement line by line,
+// so that a long statement never has to be held in memory
+type StatementWriter interface {
+	WriteHeader(header StatementHeader) error
+	WriteLine(line StatementLine) error
+	WriteFooter(closingBalance int64) error
+}
+
+// AccountStatementTxResult contains the results of account statement transaction
+type AccountStatementTxResult struct {
+	Account        Account         `json:"account"`
+	OpeningBalance int64           `json:"opening_balance"`
+	ClosingBalance int64           `json:"closing_balance"`
+	Lines          []StatementLine `json:"lines"`
+}
+
+// statementCollector is a StatementWriter keeping the whole statement in memory
+type statementCollector struct {
+	result AccountStatementTxResult
+}
+
+func (collector *statementCollector) WriteHeader(header StatementHeader) error {
+	collector.result.Account = header.Account
+	collector.result.OpeningBalance = header.OpeningBalance
+	collector.result.Lines = []StatementLine{}
+	return nil
+}
+
+func (collector *statementCollector) WriteLine(line StatementLine) error {
+	collector.result.Lines = append(collector.result.Lines, line)
+	return nil
+}
+
+func (collector *statementCollector) WriteFooter(closingBalance int64) error {
+	collector.result.ClosingBalance = closingBalance
+	return nil
+}
+
+// AccountStatementTx lists the entries of an account created in [From, To) with their running balances
+func (store *SQLStore) AccountStatementTx(ctx context.Context, arg AccountStatementTxParams) (AccountStatementTxResult, error) {
+	collector := &statementCollector{}
+	err := store.StreamAccountStatementTx(ctx, arg, collector)
+	return collector.result, err
+}
+
+// StreamAccountStatementTx writes the entries of an account created in [From, To) with their running balances
+// to the writer while they are read from the database.
+// The opening balance is worked back from the current balance, so the statement also holds for
+// balances that didn't start from zero. It reads from a single snapshot to stay consistent with
+// concurrent transfers.
+func (store *SQLStore) StreamAccountStatementTx(ctx context.Context, arg AccountStatementTxParams, w StatementWriter) error {
+	opts := pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}
+	return store.execTXOptions(ctx, "account_statement", opts, func(ctx context.Context, q *Queries) error {
+		account, err := q.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		sinceFrom, err := q.SumEntriesSince(ctx, SumEntriesSinceParams{
+			AccountID: arg.AccountID,
+			Since:     arg.From,
+		})
+		if err != nil {
+			return err
+		}
+
+		balance := account.Balance - sinceFrom
+		err = w.WriteHeader(StatementHeader{
+			Account:        account,
+			From:           arg.From,
+			To:             arg.To,
+			OpeningBalance: balance,
+		})
+		if err != nil {
+			return err
+		}
+
+		// the rows are scanned one at a time instead of with ListStatementEntries, which loads all of them
+		rows, err := q.db.Query(ctx, listStatementEntries, arg.AccountID, arg.From, arg.To)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var line StatementLine
+			if err := rows.Scan(
+				&line.ID,
+				&line.Amount,
+				&line.TransferID,
+				&line.CreatedAt,
+				&line.CounterpartyAccountID,
+				&line.CounterpartyOwner,
+			); err != nil {
+				return err
+			}
+			balance += line.Amount
+			line.Balance = balance
+			if err := w.WriteLine(line); err != nil {
+				return err
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		return w.WriteFooter(balance)
+	})
+}
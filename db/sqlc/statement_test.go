@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountStatementTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := fundAccount(t, createRandomAccount(t), 100)
+	account2 := createRandomAccountInCurrency(t, account1.Currency)
+	from := time.Now().Add(-time.Second)
+
+	transferResult, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        30,
+	})
+	require.NoError(t, err)
+
+	_, err = store.DepositTx(context.Background(), CashTxParams{
+		AccountID: account1.ID,
+		Amount:    5,
+	})
+	require.NoError(t, err)
+
+	statement, err := store.AccountStatementTx(context.Background(), AccountStatementTxParams{
+		AccountID: account1.ID,
+		From:      from,
+		To:        time.Now().Add(time.Second),
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, account1.ID, statement.Account.ID)
+	require.Equal(t, int64(100), statement.OpeningBalance)
+	require.Equal(t, int64(75), statement.ClosingBalance)
+	require.Equal(t, statement.Account.Balance, statement.ClosingBalance)
+	require.Len(t, statement.Lines, 2)
+
+	transferLine := statement.Lines[0]
+	require.Equal(t, int64(-30), transferLine.Amount)
+	require.Equal(t, int64(70), transferLine.Balance)
+	require.Equal(t, transferResult.Transfer.ID, *transferLine.TransferID)
+	require.Equal(t, account2.ID, transferLine.CounterpartyAccountID.Int64)
+	require.Equal(t, account2.Owner, transferLine.CounterpartyOwner.String)
+
+	depositLine := statement.Lines[1]
+	require.Equal(t, int64(5), depositLine.Amount)
+	require.Equal(t, int64(75), depositLine.Balance)
+	require.Nil(t, depositLine.TransferID)
+	require.False(t, depositLine.CounterpartyAccountID.Valid)
+
+	// a period before the entries has the opening balance as closing balance
+	statement, err = store.AccountStatementTx(context.Background(), AccountStatementTxParams{
+		AccountID: account1.ID,
+		From:      from.Add(-time.Hour),
+		To:        from,
+	})
+	require.NoError(t, err)
+	require.Empty(t, statement.Lines)
+	require.Equal(t, int64(100), statement.OpeningBalance)
+	require.Equal(t, int64(100), statement.ClosingBalance)
+}
+
+func TestAccountStatementTxChronological(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccount(t)
+	from := time.Now().Add(-time.Hour)
+
+	result1, err := store.DepositTx(context.Background(), CashTxParams{AccountID: account.ID, Amount: 10})
+	require.NoError(t, err)
+	result2, err := store.DepositTx(context.Background(), CashTxParams{AccountID: account.ID, Amount: 20})
+	require.NoError(t, err)
+
+	// the entry with the higher id happened first, like an entry committed after a slower transaction started
+	_, err = testDB.Exec(context.Background(), "UPDATE entries SET created_at = $1 WHERE id = $2",
+		from.Add(time.Minute), result2.Entry.ID)
+	require.NoError(t, err)
+
+	statement, err := store.AccountStatementTx(context.Background(), AccountStatementTxParams{
+		AccountID: account.ID,
+		From:      from,
+		To:        time.Now().Add(time.Second),
+	})
+	require.NoError(t, err)
+	require.Len(t, statement.Lines, 2)
+
+	require.Equal(t, result2.Entry.ID, statement.Lines[0].ID)
+	require.Equal(t, statement.OpeningBalance+20, statement.Lines[0].Balance)
+	require.Equal(t, result1.Entry.ID, statement.Lines[1].ID)
+	require.Equal(t, statement.ClosingBalance, statement.Lines[1].Balance)
+}
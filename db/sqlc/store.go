@@ -2,8 +2,15 @@ package db
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/codes"
 )
 
 // Store provides all function to execute db queries and transactions
@@ -14,6 +21,7 @@ type Store interface {
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
 	DeleteAccount(ctx context.Context, id int64) error
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountByCurrency(ctx context.Context, arg GetAccountByCurrencyParams) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
 	GetEntryById(ctx context.Context, id int64) (Entry, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
@@ -21,43 +29,89 @@ type Store interface {
 	ListEntry(ctx context.Context, arg ListEntryParams) ([]Entry, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
+	UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error)
+	UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusTxParams) (Account, error)
+	CloseAccountTx(ctx context.Context, arg CloseAccountTxParams) (CloseAccountTxResult, error)
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	DepositTx(ctx context.Context, arg CashTxParams) (CashTxResult, error)
+	WithdrawTx(ctx context.Context, arg CashTxParams) (CashTxResult, error)
 	GetUser(ctx context.Context, username string) (User, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	IsTokenRevoked(ctx context.Context, arg IsTokenRevokedParams) (bool, error)
+	LogoutTx(ctx context.Context, arg LogoutTxParams) error
+	LogoutAllTx(ctx context.Context, username string) error
+	IdempotentTransferTx(ctx context.Context, arg IdempotentTransferTxParams) (IdempotentTransferTxResult, error)
+	AccountStatementTx(ctx context.Context, arg AccountStatementTxParams) (AccountStatementTxResult, error)
+	StreamAccountStatementTx(ctx context.Context, arg AccountStatementTxParams, w StatementWriter) error
+	IncrementRateLimit(ctx context.Context, arg IncrementRateLimitParams) (RateLimit, error)
+	DeleteRateLimit(ctx context.Context, key string) error
+	DeleteExpiredRateLimits(ctx context.Context, resetAt time.Time) error
+	Ping(ctx context.Context) error
+	MigrationVersion(ctx context.Context) (version int64, dirty bool, err error)
 }
 
 // SQLStore provides all function to execute SQL queries and transactions
 type SQLStore struct {
 	*Queries
-	db *sql.DB
+	connPool    *pgxpool.Pool
+	revocations *revocationCache
 }
 
 var txKey = struct{}{}
 
-// NewStore creates a new Store
-func NewStore(db *sql.DB) Store {
+// ErrInsufficientFunds is returned when a transfer would take an account below its overdraft limit
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// ErrExchangeRateRequired is returned when a transfer between currencies comes without its converted amount
+var ErrExchangeRateRequired = errors.New("exchange rate required")
+
+// NewStore creates a new Store on the connection pool
+func NewStore(connPool *pgxpool.Pool) Store {
 	return &SQLStore{
-		Queries: New(db),
-		db:      db,
+		Queries:     New(connPool),
+		connPool:    connPool,
+		revocations: newRevocationCache(revocationCacheTTL),
 	}
 }
 
-// execTx executes a function within a database transaction
-func (store *SQLStore) execTX(ctx context.Context, fn func(*Queries) error) error {
-	tx, err := store.db.BeginTx(ctx, nil)
+// execTx executes a function within a database transaction, the name labels its metrics and span
+func (store *SQLStore) execTX(ctx context.Context, name string, fn func(context.Context, *Queries) error) error {
+	return store.execTXOptions(ctx, name, pgx.TxOptions{}, fn)
+}
+
+// execTXOptions executes a function within a database transaction started with the options.
+// The function gets the context of the transaction span, its queries must use it to be traced as part of the transaction.
+func (store *SQLStore) execTXOptions(ctx context.Context, name string, opts pgx.TxOptions, fn func(context.Context, *Queries) error) error {
+	timer := prometheus.NewTimer(txDuration.WithLabelValues(name))
+	defer timer.ObserveDuration()
+	ctx, span := startTxSpan(ctx, name)
+	defer span.End()
+
+	tx, err := store.connPool.BeginTx(ctx, opts)
 	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
 		return err
 	}
 
 	q := New(tx)
-	err = fn(q)
+	err = fn(ctx, q)
 	if err != nil {
-		if rbErr := tx.Rollback(); rbErr != nil {
+		txRollbacks.WithLabelValues(name).Inc()
+		span.SetStatus(codes.Error, err.Error())
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
 			return fmt.Errorf("tx err:%v, rb err:%v", err, rbErr)
 		}
+		return err
 	}
 
-	return tx.Commit()
+	err = tx.Commit(ctx)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
 }
 
 // TransferTxParams contains the input parameters of transfer transaction
@@ -65,6 +119,10 @@ type TransferTxParams struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	Amount        int64 `json:"amount"`
+	// ConvertedAmount is the amount credited in the currency of the to account,
+	// it defaults to Amount when both accounts have the same currency
+	ConvertedAmount int64  `json:"converted_amount"`
+	ExchangeRate    string `json:"exchange_rate"`
 }
 
 // TransferTxResult contains the results of transfer transaction
@@ -76,47 +134,125 @@ type TransferTxResult struct {
 	ToEntry     Entry    `json:"to_entry"`
 }
 
-// TransferTx performs a transfer from one account to the other
+// TransferTx performs a transfer from one account to the other, both accounts must be active
 // It creates a transfer record, add account entries, and update accounts' balance within a db transaction
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
-	err := store.execTX(ctx, func(q *Queries) error {
+	err := store.execTX(ctx, "transfer", func(ctx context.Context, q *Queries) error {
 		var err error
+		result, err = transfer(ctx, q, arg)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
 
-		result.Transfer, err = q.CreateTransfer(ctx, (CreateTransferParams)(arg))
-		if err != nil {
-			return err
-		}
+	observeTransfer(result)
+	return result, nil
+}
 
-		// Create two entry
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+// transfer moves the money between the accounts with the queries of an ongoing transaction
+func transfer(ctx context.Context, q *Queries, arg TransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	fromAccount, toAccount, err := lockAccounts(ctx, q, arg.FromAccountID, arg.ToAccountID)
+	if err != nil {
+		return result, err
+	}
+
+	err = checkActive(fromAccount)
+	if err != nil {
+		return result, err
+	}
+	err = checkActive(toAccount)
+	if err != nil {
+		return result, err
+	}
+
+	return moveMoney(ctx, q, arg, fromAccount, toAccount)
+}
+
+// moveMoney records the transfer between the locked accounts and updates their balances,
+// the caller checks the status of the accounts
+func moveMoney(ctx context.Context, q *Queries, arg TransferTxParams, fromAccount Account, toAccount Account) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := checkFunds(fromAccount, arg.Amount)
+	if err != nil {
+		return result, err
+	}
+
+	if fromAccount.Currency == toAccount.Currency {
+		arg.ConvertedAmount = arg.Amount
+		arg.ExchangeRate = "1"
+	} else if arg.ConvertedAmount <= 0 || arg.ExchangeRate == "" {
+		return result, fmt.Errorf("%w: %s to %s", ErrExchangeRateRequired, fromAccount.Currency, toAccount.Currency)
+	}
+
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID:   arg.FromAccountID,
+		ToAccountID:     arg.ToAccountID,
+		Amount:          arg.Amount,
+		ConvertedAmount: arg.ConvertedAmount,
+		ExchangeRate:    arg.ExchangeRate,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// Create two entry, each in the currency of its account
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  arg.FromAccountID,
+		Amount:     -arg.Amount,
+		TransferID: &result.Transfer.ID,
+	})
+	if err != nil {
+		return result, err
+	}
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  arg.ToAccountID,
+		Amount:     arg.ConvertedAmount,
+		TransferID: &result.Transfer.ID,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// Update accounts' balance
+	if arg.FromAccountID < arg.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.ConvertedAmount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.ConvertedAmount, arg.FromAccountID, -arg.Amount)
+	}
+	return result, err
+}
 
-		// Update accounts' balance
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+// lockAccounts locks both accounts of a transfer for the rest of the transaction.
+// The accounts are locked in the order of their ids like in addMoney to avoid deadlocks.
+func lockAccounts(ctx context.Context, q *Queries, fromAccountID int64, toAccountID int64) (fromAccount Account, toAccount Account, err error) {
+	if fromAccountID < toAccountID {
+		fromAccount, err = q.GetAccountForUpdate(ctx, fromAccountID)
+		if err != nil {
+			return
 		}
+		toAccount, err = q.GetAccountForUpdate(ctx, toAccountID)
+	} else {
+		toAccount, err = q.GetAccountForUpdate(ctx, toAccountID)
 		if err != nil {
-			return err
+			return
 		}
-		return nil
-	})
+		fromAccount, err = q.GetAccountForUpdate(ctx, fromAccountID)
+	}
+	return
+}
 
-	return result, err
+// checkFunds makes sure paying the amount doesn't take the account below its overdraft limit
+func checkFunds(account Account, amount int64) error {
+	if account.Balance-amount < -account.OverdraftLimit {
+		return fmt.Errorf("%w: account [%d] balance %d, overdraft limit %d, amount %d",
+			ErrInsufficientFunds, account.ID, account.Balance, account.OverdraftLimit, amount)
+	}
+	return nil
 }
 
 func addMoney(
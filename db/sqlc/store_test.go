@@ -4,22 +4,25 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
+	"github.com/yuuban007/simplebank/util"
 )
 
 func TestTransferTX(t *testing.T) {
 	store := NewStore(testDB)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
-
-	// fmt.Println(">>before", account1.Balance, account2.Balance)
-
 	// run n concurrent transfer transactions
 	n := 6
 	amount := int64(10)
 
+	account1 := fundAccount(t, createRandomAccount(t), int64(n)*amount)
+	account2 := createRandomAccountInCurrency(t, account1.Currency)
+
+	// fmt.Println(">>before", account1.Balance, account2.Balance)
+
 	errs := make(chan error)
 	results := make(chan TransferTxResult)
 	for i := 0; i < n; i++ {
@@ -113,15 +116,15 @@ func TestTransferTX(t *testing.T) {
 func TestTransferTXDeadLock(t *testing.T) {
 	store := NewStore(testDB)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
-
-	// fmt.Println(">>before", account1.Balance, account2.Balance)
-
 	// run n concurrent transfer transactions
 	n := 30
 	amount := int64(10)
 
+	account1 := fundAccount(t, createRandomAccount(t), int64(n)*amount)
+	account2 := fundAccount(t, createRandomAccountInCurrency(t, account1.Currency), int64(n)*amount)
+
+	// fmt.Println(">>before", account1.Balance, account2.Balance)
+
 	errs := make(chan error)
 	for i := 0; i < n; i++ {
 		fromAccount := account1
@@ -161,3 +164,142 @@ func TestTransferTXDeadLock(t *testing.T) {
 	require.Equal(t, updatedAccount1.Balance, account1.Balance)
 	require.Equal(t, updatedAccount2.Balance, account2.Balance)
 }
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	amount := int64(10)
+	account1 := fundAccount(t, createRandomAccount(t), amount)
+	account2 := createRandomAccountInCurrency(t, account1.Currency)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount + 1,
+	}
+	_, err := store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// nothing is written when the transfer is rejected
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+	updatedAccount2, err := store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+
+	// the overdraft limit lets the balance go negative, but not further
+	_, err = store.UpdateAccountOverdraftLimit(context.Background(), UpdateAccountOverdraftLimitParams{
+		ID:             account1.ID,
+		OverdraftLimit: 1,
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(-1), result.FromAccount.Balance)
+
+	arg.Amount = 1
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestTransferTxMetrics(t *testing.T) {
+	store := NewStore(testDB)
+
+	amount := int64(10)
+	account1 := fundAccount(t, createRandomAccount(t), amount)
+	account2 := createRandomAccountInCurrency(t, account1.Currency)
+
+	transfers := testutil.ToFloat64(transfersTotal.WithLabelValues(account1.Currency))
+	transferred := testutil.ToFloat64(transferredAmount.WithLabelValues(account1.Currency))
+	rollbacks := testutil.ToFloat64(txRollbacks.WithLabelValues("transfer"))
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	}
+	_, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, transfers+1, testutil.ToFloat64(transfersTotal.WithLabelValues(account1.Currency)))
+	require.Equal(t, transferred+float64(amount), testutil.ToFloat64(transferredAmount.WithLabelValues(account1.Currency)))
+
+	// a rejected transfer is rolled back and moves no money
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+	require.Equal(t, rollbacks+1, testutil.ToFloat64(txRollbacks.WithLabelValues("transfer")))
+	require.Equal(t, transfers+1, testutil.ToFloat64(transfersTotal.WithLabelValues(account1.Currency)))
+}
+
+func TestTransferTxCrossCurrency(t *testing.T) {
+	store := NewStore(testDB)
+
+	amount := int64(1000)
+	account1 := fundAccount(t, createRandomAccountInCurrency(t, util.USD), amount)
+	account2 := createRandomAccountInCurrency(t, util.EUR)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	}
+	_, err := store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrExchangeRateRequired)
+
+	arg.ConvertedAmount = 920
+	arg.ExchangeRate = "0.92"
+	result, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, amount, result.Transfer.Amount)
+	require.Equal(t, arg.ConvertedAmount, result.Transfer.ConvertedAmount)
+	require.Equal(t, arg.ExchangeRate, result.Transfer.ExchangeRate)
+
+	// each entry is in the currency of its account
+	require.Equal(t, -amount, result.FromEntry.Amount)
+	require.Equal(t, arg.ConvertedAmount, result.ToEntry.Amount)
+	require.Equal(t, account1.Balance-amount, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+arg.ConvertedAmount, result.ToAccount.Balance)
+}
+
+func TestIdempotentTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	amount := int64(10)
+	account1 := fundAccount(t, createRandomAccount(t), amount)
+	account2 := createRandomAccountInCurrency(t, account1.Currency)
+
+	arg := IdempotentTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		},
+		Username:    account1.Owner,
+		Key:         util.RandomString(16),
+		RequestHash: util.RandomString(64),
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	result1, err := store.IdempotentTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, result1.Replayed)
+	require.NotZero(t, result1.Transfer.ID)
+
+	// the retry gets the first result back without transferring again
+	result2, err := store.IdempotentTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result2.Replayed)
+	require.Equal(t, result1.Transfer.ID, result2.Transfer.ID)
+	require.Equal(t, result1.FromAccount.Balance, result2.FromAccount.Balance)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-amount, updatedAccount1.Balance)
+
+	// the same key can't be used for another request
+	arg.RequestHash = util.RandomString(64)
+	_, err = store.IdempotentTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrIdempotencyKeyReused)
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/yuuban007/simplebank/db/sqlc")
+
+// QueryTracer creates a span for every query run by pgx, named after the sqlc query,
+// so that the lock waits of a transaction show up as slow GetAccountForUpdate or AddAccountBalance spans
+type QueryTracer struct{}
+
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = tracer.Start(ctx, "db."+queryName(data.SQL),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBStatement(data.SQL),
+		),
+	)
+	return ctx
+}
+
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		span.RecordError(data.Err)
+		span.SetStatus(codes.Error, data.Err.Error())
+	}
+	span.End()
+}
+
+// queryName finds the name of a sqlc query in its "-- name: GetAccount :one" header,
+// other statements like begin and commit are named after their first keyword
+func queryName(sql string) string {
+	if rest, found := strings.CutPrefix(sql, "-- name: "); found {
+		if name, _, found := strings.Cut(rest, " "); found {
+			return name
+		}
+	}
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "query"
+	}
+	return strings.ToLower(fields[0])
+}
+
+// startTxSpan creates the span around a transaction, the spans of its queries are its children
+func startTxSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "db.tx."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL),
+	)
+}
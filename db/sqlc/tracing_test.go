@@ -0,0 +1,15 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryName(t *testing.T) {
+	require.Equal(t, "GetAccountForUpdate", queryName(getAccountForUpdate))
+	require.Equal(t, "AddAccountBalance", queryName(addAccountBalance))
+	require.Equal(t, "begin", queryName("begin"))
+	require.Equal(t, "select", queryName("SELECT version, dirty FROM schema_migrations LIMIT 1"))
+	require.Equal(t, "query", queryName("  "))
+}
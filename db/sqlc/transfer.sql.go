@@ -7,27 +7,39 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  converted_amount,
+  exchange_rate
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5
 )
-RETURNING id, from_account_id, to_account_id, amount, created_at
+RETURNING id, from_account_id, to_account_id, amount, created_at, converted_amount, exchange_rate
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID   int64  `json:"from_account_id"`
+	ToAccountID     int64  `json:"to_account_id"`
+	Amount          int64  `json:"amount"`
+	ConvertedAmount int64  `json:"converted_amount"`
+	ExchangeRate    string `json:"exchange_rate"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRow(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ConvertedAmount,
+		arg.ExchangeRate,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -35,17 +47,19 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ConvertedAmount,
+		&i.ExchangeRate,
 	)
 	return i, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, converted_amount, exchange_rate FROM transfers
 WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, getTransfer, id)
+	row := q.db.QueryRow(ctx, getTransfer, id)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -53,33 +67,67 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ConvertedAmount,
+		&i.ExchangeRate,
 	)
 	return i, err
 }
 
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
-WHERE 
-    from_account_id = $1 OR
-    to_account_id = $2
-ORDER BY id
-LIMIT $3
-OFFSET $4
+SELECT t.id, t.from_account_id, t.to_account_id, t.amount, t.created_at, t.converted_amount, t.exchange_rate FROM transfers t
+JOIN accounts from_account ON from_account.id = t.from_account_id
+JOIN accounts to_account ON to_account.id = t.to_account_id
+WHERE
+  ($1::varchar IS NULL
+    OR ($2::varchar IS DISTINCT FROM 'in' AND from_account.owner = $1)
+    OR ($2::varchar IS DISTINCT FROM 'out' AND to_account.owner = $1))
+  AND ($3::bigint IS NULL
+    OR ($2::varchar IS DISTINCT FROM 'in' AND t.from_account_id = $3)
+    OR ($2::varchar IS DISTINCT FROM 'out' AND t.to_account_id = $3))
+  AND ($4::bigint IS NULL OR t.amount >= $4)
+  AND ($5::bigint IS NULL OR t.amount <= $5)
+  AND ($6::varchar IS NULL OR from_account.currency = $6)
+  AND ($7::timestamptz IS NULL OR t.created_at >= $7)
+  AND ($8::timestamptz IS NULL OR t.created_at < $8)
+  AND ($9::timestamptz IS NULL
+    OR (t.created_at, t.id) > ($9::timestamptz, $10::bigint))
+ORDER BY t.created_at, t.id
+LIMIT $12
+OFFSET $11
 `
 
 type ListTransfersParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Limit         int32 `json:"limit"`
-	Offset        int32 `json:"offset"`
+	Owner          pgtype.Text        `json:"owner"`
+	Direction      pgtype.Text        `json:"direction"`
+	AccountID      pgtype.Int8        `json:"account_id"`
+	MinAmount      pgtype.Int8        `json:"min_amount"`
+	MaxAmount      pgtype.Int8        `json:"max_amount"`
+	Currency       pgtype.Text        `json:"currency"`
+	FromTime       pgtype.Timestamptz `json:"from_time"`
+	ToTime         pgtype.Timestamptz `json:"to_time"`
+	AfterCreatedAt pgtype.Timestamptz `json:"after_created_at"`
+	AfterID        pgtype.Int8        `json:"after_id"`
+	Offset         int32              `json:"offset"`
+	Limit          int32              `json:"limit"`
 }
 
+// every filter is optional, direction is 'in' or 'out' relative to the owner and the account.
+// amount and currency are those of the from account, the currency the transfer was made in.
+// after_created_at and after_id are the keyset cursor of the last transfer of the previous page.
 func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
-	rows, err := q.db.QueryContext(ctx, listTransfers,
-		arg.FromAccountID,
-		arg.ToAccountID,
-		arg.Limit,
+	rows, err := q.db.Query(ctx, listTransfers,
+		arg.Owner,
+		arg.Direction,
+		arg.AccountID,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.Currency,
+		arg.FromTime,
+		arg.ToTime,
+		arg.AfterCreatedAt,
+		arg.AfterID,
 		arg.Offset,
+		arg.Limit,
 	)
 	if err != nil {
 		return nil, err
@@ -94,14 +142,13 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ConvertedAmount,
+			&i.ExchangeRate,
 		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
 	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
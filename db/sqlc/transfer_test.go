@@ -3,16 +3,21 @@ package db
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 	"github.com/yuuban007/simplebank/util"
 )
 
 func createRandomTransfer(t *testing.T, account1, account2 Account) Transfer {
+	amount := util.RandomMoney()
 	arg := CreateTransferParams{
-		FromAccountID: account1.ID,
-		ToAccountID:   account2.ID,
-		Amount:        util.RandomMoney(),
+		FromAccountID:   account1.ID,
+		ToAccountID:     account2.ID,
+		Amount:          amount,
+		ConvertedAmount: amount,
+		ExchangeRate:    "1",
 	}
 
 	// transfer, err := testStore.CreateTransfer(context.Background(), arg)
@@ -23,6 +28,8 @@ func createRandomTransfer(t *testing.T, account1, account2 Account) Transfer {
 	require.Equal(t, arg.FromAccountID, transfer.FromAccountID)
 	require.Equal(t, arg.ToAccountID, transfer.ToAccountID)
 	require.Equal(t, arg.Amount, transfer.Amount)
+	require.Equal(t, arg.ConvertedAmount, transfer.ConvertedAmount)
+	require.Equal(t, arg.ExchangeRate, transfer.ExchangeRate)
 
 	require.NotZero(t, transfer.ID)
 	require.NotZero(t, transfer.CreatedAt)
@@ -52,28 +59,61 @@ func TestCreateTransfer(t *testing.T) {
 // 	require.WithinDuration(t, transfer1.CreatedAt, transfer2.CreatedAt, time.Second)
 // }
 
-// func TestListTransfer(t *testing.T) {
-// 	account1 := createRandomAccount(t)
-// 	account2 := createRandomAccount(t)
+func TestListTransfers(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	account3 := createRandomAccount(t)
 
-// 	for i := 0; i < 5; i++ {
-// 		createRandomTransfer(t, account1, account2)
-// 		createRandomTransfer(t, account2, account1)
-// 	}
+	for i := 0; i < 3; i++ {
+		createRandomTransfer(t, account1, account2)
+		createRandomTransfer(t, account2, account1)
+	}
+	createRandomTransfer(t, account2, account3)
 
-// 	arg := ListTransfersParams{
-// 		FromAccountID: account1.ID,
-// 		ToAccountID:   account1.ID,
-// 		Limit:         5,
-// 		Offset:        5,
-// 	}
+	owner := pgtype.Text{String: account1.Owner, Valid: true}
 
-// 	transfers, err := testStore.ListTransfers(context.Background(), arg)
-// 	require.NoError(t, err)
-// 	require.Len(t, transfers, 5)
+	transfers, err := testStore.ListTransfers(context.Background(), ListTransfersParams{
+		Owner:  owner,
+		Limit:  10,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 6)
+	for _, transfer := range transfers {
+		require.True(t, transfer.FromAccountID == account1.ID || transfer.ToAccountID == account1.ID)
+	}
 
-// 	for _, transfer := range transfers {
-// 		require.NotEmpty(t, transfer)
-// 		require.True(t, transfer.FromAccountID == account1.ID || transfer.ToAccountID == account1.ID)
-// 	}
-// }
+	transfers, err = testStore.ListTransfers(context.Background(), ListTransfersParams{
+		Owner:     owner,
+		Direction: pgtype.Text{String: "out", Valid: true},
+		Limit:     10,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 3)
+	for _, transfer := range transfers {
+		require.Equal(t, account1.ID, transfer.FromAccountID)
+	}
+
+	// the account filter doesn't show transfers of other users
+	transfers, err = testStore.ListTransfers(context.Background(), ListTransfersParams{
+		Owner:     owner,
+		AccountID: pgtype.Int8{Int64: account3.ID, Valid: true},
+		Limit:     10,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+
+	transfers, err = testStore.ListTransfers(context.Background(), ListTransfersParams{
+		AccountID: pgtype.Int8{Int64: account3.ID, Valid: true},
+		Currency:  pgtype.Text{String: account2.Currency, Valid: true},
+		FromTime:  pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true},
+		MinAmount: pgtype.Int8{Int64: 0, Valid: true},
+		Limit:     10,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	require.Equal(t, account3.ID, transfers[0].ToAccountID)
+}
@@ -27,6 +27,7 @@ func createRandomUser(t *testing.T) User {
 	require.Equal(t, arg.Username, user.Username)
 	require.Equal(t, arg.FullName, user.FullName)
 	require.Equal(t, arg.Email, user.Email)
+	require.Equal(t, util.DepositorRole, user.Role)
 
 	// how to test field PasswordChangedAt?
 	// Make sure time isZero
@@ -83,7 +84,7 @@ func TestDeleteAccount(t *testing.T) {
 	account2, err2 := testStore.GetAccount(context.Background(), account1.ID)
 	require.Error(t, err2)
 	require.Empty(t, account2)
-	require.EqualError(t, err2, sql.ErrNoRows.Error())
+	require.ErrorIs(t, err2, ErrRecordNotFound)
 }
 func TestListAccount(t *testing.T) {
 	for i := 0; i < 10; i++ {
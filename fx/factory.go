@@ -0,0 +1,12 @@
+package fx
+
+import "github.com/yuuban007/simplebank/util"
+
+// NewFXRateProvider builds the FXRateProvider from FX_RATES_FILE.
+// Without a rates file only same currency transfers can be made.
+func NewFXRateProvider(config util.Config) (FXRateProvider, error) {
+	if config.FXRatesFile == "" {
+		return NewStaticProvider(nil)
+	}
+	return NewFileProvider(config.FXRatesFile)
+}
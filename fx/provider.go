@@ -0,0 +1,14 @@
+package fx
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// FXRateProvider is an interface for looking up the exchange rates of cross-currency transfers
+type FXRateProvider interface {
+	// Rate returns the rate to convert an amount in the from currency to the to currency
+	Rate(ctx context.Context, from string, to string) (Rate, error)
+}
@@ -0,0 +1,79 @@
+package fx
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// rateScale is the number of decimal places kept for an exchange rate
+const rateScale = 8
+
+var (
+	ErrInvalidRate    = errors.New("invalid exchange rate")
+	ErrAmountOverflow = errors.New("converted amount is out of range")
+)
+
+// Rate is an exact decimal exchange rate, so converting an amount never suffers from float rounding
+type Rate struct {
+	value *big.Rat
+}
+
+// One is the rate between a currency and itself
+var One = Rate{value: big.NewRat(1, 1)}
+
+// ParseRate parses a positive decimal rate like "1.0845"
+func ParseRate(s string) (Rate, error) {
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return Rate{}, fmt.Errorf("%w: %q", ErrInvalidRate, s)
+	}
+	rate, err := newRate(value)
+	if err != nil {
+		return Rate{}, fmt.Errorf("%w: %q", ErrInvalidRate, s)
+	}
+	return rate, nil
+}
+
+// newRate rounds the value to rateScale decimal places,
+// a value that isn't positive once rounded isn't a rate
+func newRate(value *big.Rat) (Rate, error) {
+	rounded, _ := new(big.Rat).SetString(value.FloatString(rateScale))
+	if rounded.Sign() <= 0 {
+		return Rate{}, ErrInvalidRate
+	}
+	return Rate{value: rounded}, nil
+}
+
+// Inverse returns the rate to convert back to the original currency.
+// It fails when the inverse rounds to zero, or on the zero Rate.
+func (rate Rate) Inverse() (Rate, error) {
+	if rate.value == nil || rate.value.Sign() <= 0 {
+		return Rate{}, ErrInvalidRate
+	}
+	inverse, err := newRate(new(big.Rat).Inv(rate.value))
+	if err != nil {
+		return Rate{}, fmt.Errorf("%w: inverse of %s", ErrInvalidRate, rate)
+	}
+	return inverse, nil
+}
+
+// Convert converts an amount with the rate, rounding half away from zero.
+// It fails when the converted amount doesn't fit in an int64.
+func (rate Rate) Convert(amount int64) (int64, error) {
+	converted := new(big.Rat).Mul(big.NewRat(amount, 1), rate.value)
+	result, err := strconv.ParseInt(converted.FloatString(0), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %d at rate %s", ErrAmountOverflow, amount, rate)
+	}
+	return result, nil
+}
+
+// String returns the rate as a decimal without trailing zeros
+func (rate Rate) String() string {
+	s := rate.value.FloatString(rateScale)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
@@ -0,0 +1,81 @@
+package fx
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRate(t *testing.T) {
+	rate, err := ParseRate("1.0845")
+	require.NoError(t, err)
+	require.Equal(t, "1.0845", rate.String())
+
+	rate, err = ParseRate("2")
+	require.NoError(t, err)
+	require.Equal(t, "2", rate.String())
+
+	// a rate that rounds to zero at the rate scale is no rate
+	for _, s := range []string{"", "abc", "0", "-1.5", "0.000000001"} {
+		_, err = ParseRate(s)
+		require.ErrorIs(t, err, ErrInvalidRate)
+	}
+}
+
+func TestRateConvert(t *testing.T) {
+	requireConvert := func(rate Rate, amount int64, expected int64) {
+		converted, err := rate.Convert(amount)
+		require.NoError(t, err)
+		require.Equal(t, expected, converted)
+	}
+
+	rate, err := ParseRate("0.92")
+	require.NoError(t, err)
+	requireConvert(rate, 1000, 920)
+	requireConvert(rate, 10, 9)
+
+	// halves are rounded away from zero
+	rate, err = ParseRate("0.5")
+	require.NoError(t, err)
+	requireConvert(rate, 3, 2)
+	requireConvert(rate, 0, 0)
+
+	requireConvert(One, 1234, 1234)
+}
+
+func TestRateConvertOverflow(t *testing.T) {
+	rate, err := ParseRate("2")
+	require.NoError(t, err)
+
+	_, err = rate.Convert(math.MaxInt64)
+	require.ErrorIs(t, err, ErrAmountOverflow)
+
+	converted, err := rate.Convert(math.MaxInt64 / 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(math.MaxInt64-1), converted)
+}
+
+func TestRateInverse(t *testing.T) {
+	rate, err := ParseRate("0.8")
+	require.NoError(t, err)
+	inverse, err := rate.Inverse()
+	require.NoError(t, err)
+	require.Equal(t, "1.25", inverse.String())
+
+	// the inverse is rounded to the rate scale
+	rate, err = ParseRate("3")
+	require.NoError(t, err)
+	inverse, err = rate.Inverse()
+	require.NoError(t, err)
+	require.Equal(t, "0.33333333", inverse.String())
+
+	// an inverse that rounds to zero and the zero Rate fail instead of panicking
+	rate, err = ParseRate("1000000000")
+	require.NoError(t, err)
+	_, err = rate.Inverse()
+	require.ErrorIs(t, err, ErrInvalidRate)
+
+	_, err = Rate{}.Inverse()
+	require.ErrorIs(t, err, ErrInvalidRate)
+}
@@ -0,0 +1,70 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// StaticProvider serves a fixed table of exchange rates
+type StaticProvider struct {
+	rates map[string]Rate
+}
+
+// NewStaticProvider creates a StaticProvider from rates keyed by currency pair like "USD/EUR".
+// The inverse of a pair is derived when it isn't listed.
+func NewStaticProvider(rates map[string]string) (*StaticProvider, error) {
+	provider := &StaticProvider{
+		rates: make(map[string]Rate, len(rates)),
+	}
+	for pair, value := range rates {
+		from, to, found := strings.Cut(pair, "/")
+		if !found || from == "" || to == "" {
+			return nil, fmt.Errorf("invalid currency pair %q, expected FROM/TO", pair)
+		}
+		rate, err := ParseRate(value)
+		if err != nil {
+			return nil, fmt.Errorf("currency pair %s: %w", pair, err)
+		}
+		// the inverse is derived for the reverse pair, it must be a rate too
+		if _, err := rate.Inverse(); err != nil {
+			return nil, fmt.Errorf("currency pair %s: %w", pair, err)
+		}
+		provider.rates[pairKey(from, to)] = rate
+	}
+	return provider, nil
+}
+
+// NewFileProvider creates a StaticProvider from a json file mapping currency pairs to rates,
+// like {"USD/EUR": "0.92"}
+func NewFileProvider(path string) (*StaticProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read exchange rates: %w", err)
+	}
+	var rates map[string]string
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return nil, fmt.Errorf("cannot parse exchange rates %s: %w", path, err)
+	}
+	return NewStaticProvider(rates)
+}
+
+// Rate implements FXRateProvider
+func (provider *StaticProvider) Rate(ctx context.Context, from string, to string) (Rate, error) {
+	if from == to {
+		return One, nil
+	}
+	if rate, ok := provider.rates[pairKey(from, to)]; ok {
+		return rate, nil
+	}
+	if rate, ok := provider.rates[pairKey(to, from)]; ok {
+		return rate.Inverse()
+	}
+	return Rate{}, fmt.Errorf("%w: %s to %s", ErrRateNotFound, from, to)
+}
+
+func pairKey(from string, to string) string {
+	return from + "/" + to
+}
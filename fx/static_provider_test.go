@@ -0,0 +1,78 @@
+package fx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuuban007/simplebank/util"
+)
+
+func TestStaticProvider(t *testing.T) {
+	provider, err := NewStaticProvider(map[string]string{
+		util.USD + "/" + util.EUR: "0.8",
+	})
+	require.NoError(t, err)
+
+	rate, err := provider.Rate(context.Background(), util.USD, util.EUR)
+	require.NoError(t, err)
+	require.Equal(t, "0.8", rate.String())
+
+	rate, err = provider.Rate(context.Background(), util.EUR, util.USD)
+	require.NoError(t, err)
+	require.Equal(t, "1.25", rate.String())
+
+	rate, err = provider.Rate(context.Background(), util.CAD, util.CAD)
+	require.NoError(t, err)
+	require.Equal(t, One, rate)
+
+	_, err = provider.Rate(context.Background(), util.USD, util.CAD)
+	require.ErrorIs(t, err, ErrRateNotFound)
+}
+
+func TestNewStaticProviderInvalidRates(t *testing.T) {
+	_, err := NewStaticProvider(map[string]string{"USDEUR": "0.8"})
+	require.Error(t, err)
+
+	_, err = NewStaticProvider(map[string]string{"USD/EUR": "-0.8"})
+	require.ErrorIs(t, err, ErrInvalidRate)
+
+	// rounds to zero, its inverse would divide by zero
+	_, err = NewStaticProvider(map[string]string{"USD/EUR": "0.000000001"})
+	require.ErrorIs(t, err, ErrInvalidRate)
+
+	// its inverse rounds to zero
+	_, err = NewStaticProvider(map[string]string{"USD/EUR": "1000000000"})
+	require.ErrorIs(t, err, ErrInvalidRate)
+}
+
+func TestNewFileProvider(t *testing.T) {
+	provider, err := NewFileProvider(filepath.Join("testdata", "rates.json"))
+	require.NoError(t, err)
+
+	rate, err := provider.Rate(context.Background(), util.USD, util.EUR)
+	require.NoError(t, err)
+	require.Equal(t, "0.92", rate.String())
+
+	_, err = NewFileProvider(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "rates.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0600))
+	_, err = NewFileProvider(path)
+	require.Error(t, err)
+}
+
+func TestNewFXRateProvider(t *testing.T) {
+	provider, err := NewFXRateProvider(util.Config{})
+	require.NoError(t, err)
+	_, err = provider.Rate(context.Background(), util.USD, util.EUR)
+	require.ErrorIs(t, err, ErrRateNotFound)
+
+	provider, err = NewFXRateProvider(util.Config{FXRatesFile: filepath.Join("testdata", "rates.json")})
+	require.NoError(t, err)
+	_, err = provider.Rate(context.Background(), util.USD, util.EUR)
+	require.NoError(t, err)
+}
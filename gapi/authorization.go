@@ -0,0 +1,109 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/pb"
+	"github.com/yuuban007/simplebank/token"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	authorizationHeaderKey  = "authorization"
+	authorizationTypeBearer = "bearer"
+)
+
+// publicMethods can be called without an access token, they are limited per peer IP.
+// The other methods are limited per peer IP before the token is checked and then per user.
+var publicMethods = map[string]bool{
+	pb.SimpleBank_CreateUser_FullMethodName: true,
+	pb.SimpleBank_LoginUser_FullMethodName:  true,
+}
+
+type authPayloadKey struct{}
+
+// authInterceptor does for gRPC what authMiddleware does for the HTTP API,
+// every method but the public ones gets the payload of a valid, unrevoked bearer token in its context
+func (server *Server) authInterceptor(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	if publicMethods[info.FullMethod] {
+		if err := limit(ctx, server.ipLimiter, peerIP(ctx)); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+
+	if err := limit(ctx, server.authIPLimiter, peerIP(ctx)); err != nil {
+		return nil, err
+	}
+	payload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := limit(ctx, server.userLimiter, payload.Username); err != nil {
+		return nil, err
+	}
+	return handler(context.WithValue(ctx, authPayloadKey{}, payload), req)
+}
+
+// authorizeUser verifies the bearer token in the authorization metadata of the request
+func (server *Server) authorizeUser(ctx context.Context) (*token.Payload, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing metadata")
+	}
+
+	values := md.Get(authorizationHeaderKey)
+	if len(values) == 0 {
+		return nil, status.Errorf(codes.Unauthenticated, "authorization header is not provided")
+	}
+
+	fields := strings.Fields(values[0])
+	if len(fields) < 2 {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid authorization head format")
+	}
+
+	authorizationType := strings.ToLower(fields[0])
+	if authorizationType != authorizationTypeBearer {
+		return nil, status.Errorf(codes.Unauthenticated, "unsupported authorization type %s", authorizationType)
+	}
+
+	payload, err := server.tokenMaker.VerifyToken(fields[1], token.AccessToken)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid access token: %s", err)
+	}
+
+	// check if the token has been revoked by a logout
+	revoked, err := server.store.IsTokenRevoked(ctx, db.IsTokenRevokedParams{
+		ID:       payload.ID,
+		Username: payload.Username,
+		IssuedAt: payload.IssuedAt,
+	})
+	if err != nil {
+		return nil, internalError(ctx, "cannot check token revocation", err)
+	}
+	if revoked {
+		return nil, status.Errorf(codes.Unauthenticated, "token has been revoked")
+	}
+
+	return payload, nil
+}
+
+// authPayload returns the payload the auth interceptor put in the context
+func authPayload(ctx context.Context) (*token.Payload, error) {
+	payload, ok := ctx.Value(authPayloadKey{}).(*token.Payload)
+	if !ok {
+		return nil, fmt.Errorf("no authorization payload in context")
+	}
+	return payload, nil
+}
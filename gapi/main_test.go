@@ -0,0 +1,65 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/ratelimit"
+	"github.com/yuuban007/simplebank/token"
+	"github.com/yuuban007/simplebank/util"
+	"google.golang.org/grpc/metadata"
+)
+
+// testLoginMaxFailures is the number of failed logins that lock a username out of a test server
+const testLoginMaxFailures = 3
+
+// newTestConfig is the configuration of the test servers, requests aren't rate limited
+func newTestConfig() util.Config {
+	return util.Config{
+		TokenSymmetricKey:    util.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+		LoginMaxFailures:     testLoginMaxFailures,
+		LoginLockoutDuration: time.Minute,
+	}
+}
+
+func newTestServer(t *testing.T, store db.Store) *Server {
+	return newTestServerWithConfig(t, newTestConfig(), store)
+}
+
+// newTestServerWithConfig creates a test server with its own limits
+func newTestServerWithConfig(t *testing.T, config util.Config, store db.Store) *Server {
+	limits, err := ratelimit.NewLimits(config, store)
+	require.NoError(t, err)
+
+	server, err := NewServer(config, store, limits)
+	require.NoError(t, err)
+
+	return server
+}
+
+// newContextWithBearerToken returns the context of an incoming request carrying an access token
+func newContextWithBearerToken(t *testing.T, tokenMaker token.Maker, username string, role string, duration time.Duration) context.Context {
+	accessToken, _, err := tokenMaker.CreateToken(username, role, token.AccessToken, duration)
+	require.NoError(t, err)
+
+	md := metadata.MD{
+		authorizationHeaderKey: []string{
+			fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken),
+		},
+	}
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+// newContextWithAuthPayload returns the context the auth interceptor hands to the methods
+func newContextWithAuthPayload(t *testing.T, tokenMaker token.Maker, username string, role string) context.Context {
+	_, payload, err := tokenMaker.CreateToken(username, role, token.AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	return context.WithValue(context.Background(), authPayloadKey{}, payload)
+}
@@ -0,0 +1,32 @@
+package gapi
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	userAgentHeader = "user-agent"
+)
+
+// Metadata is what a session records about the client
+type Metadata struct {
+	UserAgent string
+	ClientIP  string
+}
+
+func (server *Server) extractMetadata(ctx context.Context) *Metadata {
+	mtdt := &Metadata{}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if userAgents := md.Get(userAgentHeader); len(userAgents) > 0 {
+			mtdt.UserAgent = userAgents[0]
+		}
+	}
+
+	// the IP without its port, like the one the HTTP API records
+	mtdt.ClientIP = peerIP(ctx)
+
+	return mtdt
+}
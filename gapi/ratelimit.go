@@ -0,0 +1,41 @@
+package gapi
+
+import (
+	"context"
+	"log/slog"
+	"net"
+
+	"github.com/yuuban007/simplebank/ratelimit"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// limit does for gRPC what rateLimitMiddleware does for the HTTP API. The counts are the ones
+// of the HTTP API when the limits are shared.
+func limit(ctx context.Context, limiter *ratelimit.Limiter, key string) error {
+	result, err := limiter.Allow(ctx, key)
+	if err != nil {
+		// a rate limit backend that is down doesn't take the service down with it
+		slog.WarnContext(ctx, "cannot check rate limit", "error", err)
+		return nil
+	}
+	if !result.Allowed {
+		return status.Errorf(codes.ResourceExhausted, "too many requests, retry later")
+	}
+	return nil
+}
+
+// peerIP is the IP of the client without its port, so that every connection of a client counts together
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,93 @@
+package gapi
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/yuuban007/simplebank/db/mock"
+	"github.com/yuuban007/simplebank/pb"
+	"github.com/yuuban007/simplebank/util"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestPeerRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	config := newTestConfig()
+	config.RateLimitIPRequests = 2
+	config.RateLimitWindow = time.Minute
+	server := newTestServerWithConfig(t, config, store)
+
+	calls := 0
+	handler := func(ctx context.Context, req any) (any, error) {
+		calls++
+		return nil, nil
+	}
+	login := func(address string) error {
+		addr, err := net.ResolveTCPAddr("tcp", address)
+		require.NoError(t, err)
+
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+		info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_LoginUser_FullMethodName}
+		_, err = server.authInterceptor(ctx, nil, info, handler)
+		return err
+	}
+
+	// the connections of a client count together
+	require.NoError(t, login("10.0.0.1:1234"))
+	require.NoError(t, login("10.0.0.1:5678"))
+
+	err := login("10.0.0.1:1234")
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Equal(t, 2, calls)
+
+	// the other clients have their own limit
+	require.NoError(t, login("10.0.0.2:1234"))
+}
+
+func TestAuthenticatedRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).AnyTimes().Return(false, nil)
+
+	config := newTestConfig()
+	config.RateLimitUserRequests = 1
+	config.RateLimitWindow = time.Minute
+	server := newTestServerWithConfig(t, config, store)
+
+	handler := func(ctx context.Context, req any) (any, error) {
+		return nil, nil
+	}
+	getAccount := func(ctx context.Context, address string) error {
+		addr, err := net.ResolveTCPAddr("tcp", address)
+		require.NoError(t, err)
+
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: addr})
+		info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_GetAccount_FullMethodName}
+		_, err = server.authInterceptor(ctx, nil, info, handler)
+		return err
+	}
+
+	// the authenticated methods are limited per user
+	ctx := newContextWithBearerToken(t, server.tokenMaker, "user1", util.DepositorRole, time.Minute)
+	require.NoError(t, getAccount(ctx, "10.0.0.1:1234"))
+	err := getAccount(ctx, "10.0.0.2:1234")
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// and per IP before the token is checked, so invalid tokens are counted too
+	md := metadata.Pairs(authorizationHeaderKey, authorizationTypeBearer+" invalid")
+	ctx = metadata.NewIncomingContext(context.Background(), md)
+	err = getAccount(ctx, "10.0.0.3:1234")
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	err = getAccount(ctx, "10.0.0.3:1234")
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
@@ -0,0 +1,44 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
+	authPayload, err := authPayload(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "%s", err)
+	}
+
+	var violations fieldViolations
+	violations.check("currency", req.GetCurrency(), "required,currency")
+	if len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	arg := db.CreateAccountParams{
+		Owner:    authPayload.Username,
+		Balance:  0,
+		Currency: req.GetCurrency(),
+	}
+	account, err := server.store.CreateAccount(ctx, arg)
+	if err != nil {
+		switch db.ErrorCode(err) {
+		case db.UniqueViolation:
+			return nil, status.Errorf(codes.AlreadyExists, "account already exists: %s", err)
+		case db.ForeignKeyViolation:
+			return nil, status.Errorf(codes.FailedPrecondition, "owner doesn't exist: %s", err)
+		}
+		return nil, internalError(ctx, "failed to create account", err)
+	}
+
+	rsp := &pb.CreateAccountResponse{
+		Account: convertAccount(account),
+	}
+	return rsp, nil
+}
@@ -0,0 +1,93 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/fx"
+	"github.com/yuuban007/simplebank/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	authPayload, err := authPayload(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "%s", err)
+	}
+
+	if violations := validateCreateTransferRequest(req); len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	fromAccount, err := server.findAccount(ctx, req.GetFromAccountId())
+	if err != nil {
+		return nil, err
+	}
+	if fromAccount.Currency != req.GetCurrency() {
+		return nil, status.Errorf(codes.InvalidArgument, "account [%d] currency mismatch: %s vs %s",
+			fromAccount.ID, fromAccount.Currency, req.GetCurrency())
+	}
+	if fromAccount.Owner != authPayload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "from account doesn't belong to the authenticated user")
+	}
+
+	toAccount, err := server.findAccount(ctx, req.GetToAccountId())
+	if err != nil {
+		return nil, err
+	}
+
+	arg := db.TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        req.GetAmount(),
+	}
+
+	if toAccount.Currency != fromAccount.Currency {
+		rate, err := server.fxRates.Rate(ctx, fromAccount.Currency, toAccount.Currency)
+		if err != nil {
+			if errors.Is(err, fx.ErrRateNotFound) {
+				return nil, status.Errorf(codes.FailedPrecondition, "%s", err)
+			}
+			return nil, internalError(ctx, "failed to get exchange rate", err)
+		}
+
+		arg.ExchangeRate = rate.String()
+		arg.ConvertedAmount, err = rate.Convert(req.GetAmount())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%s", err)
+		}
+		if arg.ConvertedAmount <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "amount %d %s is too small to convert to %s",
+				req.GetAmount(), fromAccount.Currency, toAccount.Currency)
+		}
+	}
+
+	result, err := server.store.TransferTx(ctx, arg)
+	if err != nil {
+		var statusErr *db.AccountStatusError
+		if errors.Is(err, db.ErrInsufficientFunds) || errors.As(err, &statusErr) {
+			return nil, status.Errorf(codes.FailedPrecondition, "%s", err)
+		}
+		return nil, internalError(ctx, "failed to transfer", err)
+	}
+
+	rsp := &pb.CreateTransferResponse{
+		Transfer:    convertTransfer(result.Transfer),
+		FromAccount: convertAccount(result.FromAccount),
+		ToAccount:   convertAccount(result.ToAccount),
+		FromEntry:   convertEntry(result.FromEntry),
+		ToEntry:     convertEntry(result.ToEntry),
+	}
+	return rsp, nil
+}
+
+func validateCreateTransferRequest(req *pb.CreateTransferRequest) fieldViolations {
+	var violations fieldViolations
+	violations.check("from_account_id", req.GetFromAccountId(), "required,min=1")
+	violations.check("to_account_id", req.GetToAccountId(), "required,min=1")
+	violations.check("amount", req.GetAmount(), "required,gt=0")
+	violations.check("currency", req.GetCurrency(), "required,currency")
+	return violations
+}
@@ -0,0 +1,50 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/pb"
+	"github.com/yuuban007/simplebank/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	if violations := validateCreateUserRequest(req); len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	hashedPassword, err := util.HashPassword(req.GetPassword())
+	if err != nil {
+		return nil, internalError(ctx, "failed to hash password", err)
+	}
+
+	arg := db.CreateUserParams{
+		Username:       req.GetUsername(),
+		HashedPassword: hashedPassword,
+		FullName:       req.GetFullName(),
+		Email:          req.GetEmail(),
+	}
+	user, err := server.store.CreateUser(ctx, arg)
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			return nil, status.Errorf(codes.AlreadyExists, "username or email already exists: %s", err)
+		}
+		return nil, internalError(ctx, "failed to create user", err)
+	}
+
+	rsp := &pb.CreateUserResponse{
+		User: convertUser(user),
+	}
+	return rsp, nil
+}
+
+func validateCreateUserRequest(req *pb.CreateUserRequest) fieldViolations {
+	var violations fieldViolations
+	violations.check("username", req.GetUsername(), "required,alphanum")
+	violations.check("password", req.GetPassword(), "required,min=8")
+	violations.check("full_name", req.GetFullName(), "required,alphanum")
+	violations.check("email", req.GetEmail(), "required,email")
+	return violations
+}
@@ -0,0 +1,46 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/yuuban007/simplebank/access"
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) GetTransfer(ctx context.Context, req *pb.GetTransferRequest) (*pb.GetTransferResponse, error) {
+	authPayload, err := authPayload(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "%s", err)
+	}
+
+	var violations fieldViolations
+	violations.check("id", req.GetId(), "required,min=1")
+	if len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, req.GetId())
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "transfer [%d] not found", req.GetId())
+		}
+		return nil, internalError(ctx, "failed to get transfer", err)
+	}
+
+	allowed, err := access.CanViewTransfer(ctx, server.store, authPayload, transfer)
+	if err != nil {
+		return nil, internalError(ctx, "failed to get account", err)
+	}
+	if !allowed {
+		return nil, status.Errorf(codes.PermissionDenied, "transfer doesn't touch any account of the authenticated user")
+	}
+
+	rsp := &pb.GetTransferResponse{
+		Transfer: convertTransfer(transfer),
+	}
+	return rsp, nil
+}
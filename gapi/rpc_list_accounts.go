@@ -0,0 +1,42 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	authPayload, err := authPayload(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "%s", err)
+	}
+
+	var violations fieldViolations
+	violations.check("page_id", req.GetPageId(), "required,min=1")
+	violations.check("page_size", req.GetPageSize(), "required,min=5,max=10")
+	if len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	arg := db.ListAccountParams{
+		Owner:  authPayload.Username,
+		Limit:  req.GetPageSize(),
+		Offset: req.GetPageSize() * (req.GetPageId() - 1),
+	}
+	accounts, err := server.store.ListAccount(ctx, arg)
+	if err != nil {
+		return nil, internalError(ctx, "failed to list accounts", err)
+	}
+
+	rsp := &pb.ListAccountsResponse{
+		Accounts: make([]*pb.Account, len(accounts)),
+	}
+	for i, account := range accounts {
+		rsp.Accounts[i] = convertAccount(account)
+	}
+	return rsp, nil
+}
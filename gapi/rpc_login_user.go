@@ -0,0 +1,74 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/yuuban007/simplebank/access"
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/pb"
+	"github.com/yuuban007/simplebank/token"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	if violations := validateLoginUserRequest(req); len(violations) > 0 {
+		return nil, invalidArgumentError(violations)
+	}
+
+	user, err := server.authenticator.Login(ctx, req.GetUsername(), req.GetPassword())
+	if err != nil {
+		var lockedErr *access.LoginLockedError
+		if errors.As(err, &lockedErr) {
+			return nil, status.Errorf(codes.ResourceExhausted, "%s", lockedErr)
+		}
+		if errors.Is(err, access.ErrInvalidCredentials) {
+			return nil, status.Errorf(codes.Unauthenticated, "%s", err)
+		}
+		return nil, internalError(ctx, "failed to find user", err)
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, token.AccessToken, server.config.AccessTokenDuration)
+	if err != nil {
+		return nil, internalError(ctx, "failed to create access token", err)
+	}
+
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, token.RefreshToken, server.config.RefreshTokenDuration)
+	if err != nil {
+		return nil, internalError(ctx, "failed to create refresh token", err)
+	}
+
+	// the refresh token id is used as the session id
+	mtdt := server.extractMetadata(ctx)
+	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
+		ID:           refreshPayload.ID,
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		UserAgent:    mtdt.UserAgent,
+		ClientIp:     mtdt.ClientIP,
+		IsBlocked:    false,
+		ExpiresAt:    refreshPayload.ExpiredAt,
+	})
+	if err != nil {
+		return nil, internalError(ctx, "failed to create session", err)
+	}
+
+	rsp := &pb.LoginUserResponse{
+		User:                  convertUser(user),
+		SessionId:             session.ID.String(),
+		AccessToken:           accessToken,
+		RefreshToken:          refreshToken,
+		AccessTokenExpiresAt:  timestamppb.New(accessPayload.ExpiredAt),
+		RefreshTokenExpiresAt: timestamppb.New(refreshPayload.ExpiredAt),
+	}
+	return rsp, nil
+}
+
+func validateLoginUserRequest(req *pb.LoginUserRequest) fieldViolations {
+	var violations fieldViolations
+	violations.check("username", req.GetUsername(), "required,alphanum")
+	violations.check("password", req.GetPassword(), "required,min=8")
+	return violations
+}
@@ -0,0 +1,94 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/yuuban007/simplebank/access"
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/fx"
+	"github.com/yuuban007/simplebank/pb"
+	"github.com/yuuban007/simplebank/ratelimit"
+	"github.com/yuuban007/simplebank/token"
+	"github.com/yuuban007/simplebank/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// Server serves gRPC requests for banking service.
+type Server struct {
+	pb.UnimplementedSimpleBankServer
+	config        util.Config
+	store         db.Store
+	tokenMaker    token.Maker
+	fxRates       fx.FXRateProvider
+	ipLimiter     *ratelimit.Limiter
+	authIPLimiter *ratelimit.Limiter
+	userLimiter   *ratelimit.Limiter
+	authenticator *access.Authenticator
+	grpcServer    *grpc.Server
+}
+
+// NewServer creates a new gRPC server, the limits may be shared with the HTTP server.
+func NewServer(config util.Config, store db.Store, limits *ratelimit.Limits) (*Server, error) {
+	tokenMaker, err := token.NewMaker(config)
+	if err != nil {
+		return nil, fmt.Errorf("can not create token maker %w", err)
+	}
+
+	fxRates, err := fx.NewFXRateProvider(config)
+	if err != nil {
+		return nil, fmt.Errorf("can not create exchange rate provider %w", err)
+	}
+
+	server := &Server{
+		config:        config,
+		store:         store,
+		tokenMaker:    tokenMaker,
+		fxRates:       fxRates,
+		ipLimiter:     limits.IP,
+		authIPLimiter: limits.AuthIP,
+		userLimiter:   limits.User,
+		authenticator: access.NewAuthenticator(store, limits.Lockout),
+	}
+
+	server.grpcServer = grpc.NewServer(grpc.UnaryInterceptor(server.authInterceptor))
+	pb.RegisterSimpleBankServer(server.grpcServer, server)
+	// reflection lets tools like grpcurl and evans discover the service
+	reflection.Register(server.grpcServer)
+	return server, nil
+}
+
+// Start runs the gRPC server on a specific address until Shutdown is called.
+func (server *Server) Start(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s %w", address, err)
+	}
+
+	err = server.grpcServer.Serve(listener)
+	if errors.Is(err, grpc.ErrServerStopped) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops accepting connections and waits for the RPCs in flight to finish,
+// the RPCs still running when the context is done are cancelled.
+func (server *Server) Shutdown(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		server.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		server.grpcServer.Stop()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,29 @@
+package gapi
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/yuuban007/simplebank/util"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// validate checks the request fields with the same rules as the binding tags of the HTTP API
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterValidation("currency", func(fieldLevel validator.FieldLevel) bool {
+		currency, ok := fieldLevel.Field().Interface().(string)
+		return ok && util.IsSupportedCurrency(currency)
+	})
+	return v
+}
+
+// fieldViolations collects the violations of the checked fields
+type fieldViolations []*errdetails.BadRequest_FieldViolation
+
+// check adds a violation when the value doesn't pass the validator tag
+func (violations *fieldViolations) check(field string, value any, tag string) {
+	if err := validate.Var(value, tag); err != nil {
+		*violations = append(*violations, fieldViolation(field, err))
+	}
+}
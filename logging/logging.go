@@ -0,0 +1,41 @@
+// Package logging builds the structured logger of the server and
+// redacts the secrets out of the request bodies it logs.
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/yuuban007/simplebank/util"
+)
+
+// Constants for all supported log formats
+const (
+	JSONFormat    = "json"
+	ConsoleFormat = "console"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported log format")
+
+// New builds the logger writing to w in the format of LOG_FORMAT, JSON by default,
+// and dropping the records below LOG_LEVEL, info by default
+func New(w io.Writer, config util.Config) (*slog.Logger, error) {
+	var level slog.Level
+	if config.LogLevel != "" {
+		if err := level.UnmarshalText([]byte(config.LogLevel)); err != nil {
+			return nil, fmt.Errorf("invalid log level %s %w", config.LogLevel, err)
+		}
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	switch strings.ToLower(config.LogFormat) {
+	case "", JSONFormat:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case ConsoleFormat:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, config.LogFormat)
+}
@@ -0,0 +1,36 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuuban007/simplebank/util"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, util.Config{})
+	require.NoError(t, err)
+
+	// json at info level by default
+	logger.Debug("hidden")
+	logger.Info("shown", "key", "value")
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "shown", record["msg"])
+	require.Equal(t, "value", record["key"])
+
+	buf.Reset()
+	logger, err = New(&buf, util.Config{LogFormat: ConsoleFormat, LogLevel: "debug"})
+	require.NoError(t, err)
+	logger.Debug("shown", "key", "value")
+	require.Contains(t, buf.String(), "level=DEBUG msg=shown key=value")
+
+	_, err = New(&buf, util.Config{LogFormat: "xml"})
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	_, err = New(&buf, util.Config{LogLevel: "verbose"})
+	require.Error(t, err)
+}
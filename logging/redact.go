@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Redacted replaces the secrets in the logs
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are the parts of the field names whose values are never logged
+var sensitiveKeys = []string{"password", "token", "secret", "authorization"}
+
+// RedactJSON returns the JSON body with the values of the password, token and secret fields replaced,
+// at any depth. A body that isn't JSON is redacted as a whole since its secrets can't be found.
+func RedactJSON(body []byte) string {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return Redacted
+	}
+
+	data, err := json.Marshal(redact(value))
+	if err != nil {
+		return Redacted
+	}
+	return string(data)
+}
+
+func redact(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if isSensitive(key) {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = redact(field)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redact(item)
+		}
+	}
+	return value
+}
+
+// isSensitive reports whether the value of the field or header must not be logged
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, key := range sensitiveKeys {
+		if strings.Contains(name, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactJSON(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name:     "Password",
+			body:     `{"username":"alice","password":"secret123"}`,
+			expected: `{"password":"[REDACTED]","username":"alice"}`,
+		},
+		{
+			name:     "Tokens",
+			body:     `{"refresh_token":"v2.local.abc","AccessToken":"v2.local.def","amount":10}`,
+			expected: `{"AccessToken":"[REDACTED]","amount":10,"refresh_token":"[REDACTED]"}`,
+		},
+		{
+			name:     "Nested",
+			body:     `{"users":[{"username":"alice","password":"a"},{"username":"bob","new_password":"b"}]}`,
+			expected: `{"users":[{"password":"[REDACTED]","username":"alice"},{"new_password":"[REDACTED]","username":"bob"}]}`,
+		},
+		{
+			name:     "WholeObject",
+			body:     `{"secret":{"key":"value"}}`,
+			expected: `{"secret":"[REDACTED]"}`,
+		},
+		{
+			name:     "NotJSON",
+			body:     `password=secret123`,
+			expected: Redacted,
+		},
+		{
+			name:     "Truncated",
+			body:     `{"username":"alice","password":"secr`,
+			expected: Redacted,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, RedactJSON([]byte(tc.body)))
+		})
+	}
+}
+
+func TestIsSensitive(t *testing.T) {
+	require.True(t, isSensitive("password"))
+	require.True(t, isSensitive("Authorization"))
+	require.True(t, isSensitive("refresh_token"))
+	require.True(t, isSensitive("CURSOR_SECRET_KEY"))
+	require.False(t, isSensitive("username"))
+	require.False(t, isSensitive("amount"))
+}
@@ -1,32 +1,225 @@
 package main
 
 import (
-	"database/sql"
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/yuuban007/simplebank/api"
 	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/gapi"
+	"github.com/yuuban007/simplebank/logging"
+	"github.com/yuuban007/simplebank/ratelimit"
+	"github.com/yuuban007/simplebank/tracing"
 	"github.com/yuuban007/simplebank/util"
+	"golang.org/x/sync/errgroup"
 )
 
+// the backoff between attempts to reach the database at startup
+const (
+	dbConnectInitialBackoff = 500 * time.Millisecond
+	dbConnectMaxBackoff     = 10 * time.Second
+)
+
+// interruptSignals start a graceful shutdown of the servers
+var interruptSignals = []os.Signal{
+	os.Interrupt,
+	syscall.SIGTERM,
+}
+
 func main() {
 	config, err := util.LoadConfig(".")
 	if err != nil {
-		log.Fatal("cannot load configuration", err)
+		fatal("cannot load configuration", err)
+	}
+
+	logger, err := logging.New(os.Stdout, config)
+	if err != nil {
+		fatal("cannot create logger", err)
+	}
+	slog.SetDefault(logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), interruptSignals...)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, config)
+	if err != nil {
+		fatal("cannot set up tracing", err)
+	}
+
+	connPool, err := connectDB(ctx, config)
+	if err != nil {
+		fatal("cannot connect to db", err)
+	}
+	store := db.NewStore(connPool)
+
+	// both servers count the hits of a client together
+	limits, err := ratelimit.NewLimits(config, store)
+	if err != nil {
+		fatal("cannot create rate limits", err)
+	}
+
+	waitGroup, ctx := errgroup.WithContext(ctx)
+	runGrpcServer(ctx, waitGroup, config, store, limits)
+	runGinServer(ctx, waitGroup, config, store, limits)
+	runMetricsServer(ctx, waitGroup, config)
+
+	err = waitGroup.Wait()
+	// the servers are drained, no request can use the pool anymore
+	connPool.Close()
+	flushTracing(shutdownTracing, config)
+	if err != nil {
+		fatal("error from wait group", err)
+	}
+	slog.Info("server stopped")
+}
+
+// fatal logs the error and exits, like log.Fatal does
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// connectDB waits for postgres to accept connections, retrying with an exponential backoff
+// so that the server can start while the database is still coming up
+func connectDB(ctx context.Context, config util.Config) (*pgxpool.Pool, error) {
+	// the pool connects lazily, this only fails on an invalid DB_SOURCE which no retry can fix
+	poolConfig, err := pgxpool.ParseConfig(config.DBSource)
+	if err != nil {
+		return nil, err
 	}
-	conn, err := sql.Open(config.DBDriver, config.DBSource)
+	poolConfig.ConnConfig.Tracer = db.QueryTracer{}
+
+	connPool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
-		log.Fatal("cannot connect to db", err)
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.DBConnectTimeout)
+	defer cancel()
+
+	backoff := dbConnectInitialBackoff
+	for {
+		err = connPool.Ping(ctx)
+		if err == nil {
+			return connPool, nil
+		}
+
+		slog.Warn("cannot reach db", "retry_in", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			connPool.Close()
+			return nil, fmt.Errorf("db is still unreachable after %s %w", config.DBConnectTimeout, err)
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, dbConnectMaxBackoff)
 	}
-	store := db.NewStore(conn)
-	server, err := api.NewServer(config, store)
+}
+
+// flushTracing exports the spans still buffered before the process exits
+func flushTracing(shutdown func(context.Context) error, config util.Config) {
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		slog.Error("cannot flush traces", "error", err)
+	}
+}
+
+func runGrpcServer(ctx context.Context, waitGroup *errgroup.Group, config util.Config, store db.Store, limits *ratelimit.Limits) {
+	server, err := gapi.NewServer(config, store, limits)
 	if err != nil {
-		log.Fatal("can not create server")
+		fatal("can not create gRPC server", err)
 	}
 
-	err = server.Start(config.ServerAddress)
+	waitGroup.Go(func() error {
+		slog.Info("start gRPC server", "address", config.GRPCServerAddress)
+		err := server.Start(config.GRPCServerAddress)
+		if err != nil {
+			return fmt.Errorf("cannot start gRPC server %w", err)
+		}
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+		slog.Info("graceful shutdown gRPC server")
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+		defer cancel()
+		err := server.Shutdown(shutdownCtx)
+		if err != nil {
+			return fmt.Errorf("cannot shut down gRPC server gracefully %w", err)
+		}
+		slog.Info("gRPC server is stopped")
+		return nil
+	})
+}
+
+func runGinServer(ctx context.Context, waitGroup *errgroup.Group, config util.Config, store db.Store, limits *ratelimit.Limits) {
+	server, err := api.NewServer(config, store, limits)
 	if err != nil {
-		log.Fatal("cannot start server", err)
+		fatal("can not create server", err)
+	}
+
+	waitGroup.Go(func() error {
+		slog.Info("start HTTP server", "address", config.ServerAddress)
+		err := server.Start(config.ServerAddress)
+		if err != nil {
+			return fmt.Errorf("cannot start HTTP server %w", err)
+		}
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+		slog.Info("graceful shutdown HTTP server")
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+		defer cancel()
+		err := server.Shutdown(shutdownCtx)
+		if err != nil {
+			return fmt.Errorf("cannot shut down HTTP server gracefully %w", err)
+		}
+		slog.Info("HTTP server is stopped")
+		return nil
+	})
+}
+
+// runMetricsServer serves the Prometheus metrics on their own address, the scrapers reach it
+// from inside the network while the API port is public. An empty METRICS_ADDRESS turns it off.
+func runMetricsServer(ctx context.Context, waitGroup *errgroup.Group, config util.Config) {
+	if config.MetricsAddress == "" {
+		return
 	}
+	server := api.NewMetricsServer(config)
+
+	waitGroup.Go(func() error {
+		slog.Info("start metrics server", "address", config.MetricsAddress)
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("cannot start metrics server %w", err)
+		}
+		return nil
+	})
+
+	waitGroup.Go(func() error {
+		<-ctx.Done()
+		slog.Info("graceful shutdown metrics server")
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+		defer cancel()
+		err := server.Shutdown(shutdownCtx)
+		if err != nil {
+			return fmt.Errorf("cannot shut down metrics server gracefully %w", err)
+		}
+		slog.Info("metrics server is stopped")
+		return nil
+	})
 }
@@ -0,0 +1,23 @@
+// Package ratelimit counts hits per key in fixed windows to limit the requests of a client
+// and to lock out the usernames that fail to log in too many times.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Window is the count of hits of a key since its window started
+type Window struct {
+	Hits    int64
+	ResetAt time.Time
+}
+
+// Backend stores the hit counts. Its operations map to Redis INCR with PEXPIRE NX and DEL,
+// so the counts can live in any store that can increment a key atomically and expire it.
+type Backend interface {
+	// Increment adds a hit to the key, the first hit after the previous window ended starts a new window
+	Increment(ctx context.Context, key string, window time.Duration) (Window, error)
+	// Reset forgets the hits of the key
+	Reset(ctx context.Context, key string) error
+}
@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/util"
+)
+
+// Constants for all supported backends
+const (
+	MemoryBackendType   = "memory"
+	PostgresBackendType = "postgres"
+)
+
+var ErrUnsupportedBackend = errors.New("unsupported rate limit backend")
+
+// NewBackend builds the Backend selected by RATE_LIMIT_BACKEND, counts are kept in memory by default
+func NewBackend(config util.Config, store db.Store) (Backend, error) {
+	switch config.RateLimitBackend {
+	case "", MemoryBackendType:
+		return NewMemoryBackend(), nil
+	case PostgresBackendType:
+		return NewPostgresBackend(store), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedBackend, config.RateLimitBackend)
+}
+
+// Limits are the limiters and the login lockout of the servers. They are built once and shared
+// by the HTTP and the gRPC servers, so that a client can't get twice the hits by using both.
+type Limits struct {
+	// IP limits the anonymous requests per client IP
+	IP *Limiter
+	// AuthIP limits per client IP the requests that need a token, before the token is checked,
+	// so that a flood of invalid tokens is limited too. An IP gets the budget of a user.
+	AuthIP *Limiter
+	// User limits the authenticated requests per user
+	User    *Limiter
+	Lockout *Lockout
+}
+
+// NewLimits builds the limits from the configuration on top of a single backend
+func NewLimits(config util.Config, store db.Store) (*Limits, error) {
+	backend, err := NewBackend(config, store)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Limits{
+		IP:      NewLimiter(backend, "ip:", config.RateLimitIPRequests, config.RateLimitWindow),
+		AuthIP:  NewLimiter(backend, "auth-ip:", config.RateLimitUserRequests, config.RateLimitWindow),
+		User:    NewLimiter(backend, "user:", config.RateLimitUserRequests, config.RateLimitWindow),
+		Lockout: NewLockout(backend, config.LoginMaxFailures, config.LoginLockoutDuration),
+	}, nil
+}
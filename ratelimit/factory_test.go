@@ -0,0 +1,29 @@
+package ratelimit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/yuuban007/simplebank/db/mock"
+	"github.com/yuuban007/simplebank/util"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNewBackend(t *testing.T) {
+	store := mockdb.NewMockStore(gomock.NewController(t))
+
+	backend, err := NewBackend(util.Config{}, store)
+	require.NoError(t, err)
+	require.IsType(t, &MemoryBackend{}, backend)
+
+	backend, err = NewBackend(util.Config{RateLimitBackend: MemoryBackendType}, store)
+	require.NoError(t, err)
+	require.IsType(t, &MemoryBackend{}, backend)
+
+	backend, err = NewBackend(util.Config{RateLimitBackend: PostgresBackendType}, store)
+	require.NoError(t, err)
+	require.IsType(t, &PostgresBackend{}, backend)
+
+	_, err = NewBackend(util.Config{RateLimitBackend: "redis"}, store)
+	require.ErrorIs(t, err, ErrUnsupportedBackend)
+}
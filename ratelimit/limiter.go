@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Result tells whether a hit is allowed, and when the client may try again when it isn't
+type Result struct {
+	Allowed    bool
+	Limit      int64
+	Remaining  int64
+	RetryAfter time.Duration
+}
+
+// Limiter allows a number of hits per key in each window
+type Limiter struct {
+	backend Backend
+	prefix  string
+	limit   int64
+	window  time.Duration
+}
+
+// NewLimiter creates a limiter of limit hits per window, the prefix keeps its keys apart from the other limiters.
+// A limit of zero allows every hit.
+func NewLimiter(backend Backend, prefix string, limit int64, window time.Duration) *Limiter {
+	return &Limiter{
+		backend: backend,
+		prefix:  prefix,
+		limit:   limit,
+		window:  window,
+	}
+}
+
+// Allow records a hit of the key and reports whether it is within the limit
+func (limiter *Limiter) Allow(ctx context.Context, key string) (Result, error) {
+	if limiter.limit <= 0 {
+		return Result{Allowed: true}, nil
+	}
+
+	window, err := limiter.backend.Increment(ctx, limiter.prefix+key, limiter.window)
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{
+		Allowed:   window.Hits <= limiter.limit,
+		Limit:     limiter.limit,
+		Remaining: max(limiter.limit-window.Hits, 0),
+	}
+	if !result.Allowed {
+		result.RetryAfter = time.Until(window.ResetAt)
+	}
+	return result, nil
+}
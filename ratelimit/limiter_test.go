@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuuban007/simplebank/util"
+)
+
+func TestLimiter(t *testing.T) {
+	backend := NewMemoryBackend()
+	limiter := NewLimiter(backend, "ip:", 2, time.Minute)
+	key := util.RandomString(8)
+
+	result, err := limiter.Allow(context.Background(), key)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, int64(2), result.Limit)
+	require.Equal(t, int64(1), result.Remaining)
+
+	result, err = limiter.Allow(context.Background(), key)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Zero(t, result.Remaining)
+	require.Zero(t, result.RetryAfter)
+
+	result, err = limiter.Allow(context.Background(), key)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Zero(t, result.Remaining)
+	require.InDelta(t, time.Minute, result.RetryAfter, float64(time.Second))
+
+	// the prefix keeps the keys of the limiters apart
+	other := NewLimiter(backend, "user:", 2, time.Minute)
+	result, err = other.Allow(context.Background(), key)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+}
+
+func TestLimiterDisabled(t *testing.T) {
+	limiter := NewLimiter(NewMemoryBackend(), "ip:", 0, time.Minute)
+	key := util.RandomString(8)
+
+	for i := 0; i < 10; i++ {
+		result, err := limiter.Allow(context.Background(), key)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+	}
+}
@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// lockoutPrefix keeps the failure counts apart from the request counts in the backend
+const lockoutPrefix = "login:"
+
+// Lockout locks a username out for a while after too many failed logins.
+// Every attempt is counted before its password is checked, so that parallel guesses can't
+// all pass a check of the count made before any of them failed. The window starts with
+// the first attempt, so the username is locked until it ends.
+type Lockout struct {
+	backend     Backend
+	maxFailures int64
+	duration    time.Duration
+}
+
+// NewLockout creates a lockout after maxFailures failures within duration, zero failures never locks out
+func NewLockout(backend Backend, maxFailures int64, duration time.Duration) *Lockout {
+	return &Lockout{
+		backend:     backend,
+		maxFailures: maxFailures,
+		duration:    duration,
+	}
+}
+
+// Attempt records a login attempt of the username and reports whether it is locked out,
+// with the time left when it is. The attempt counts as a failure until Succeed clears it.
+func (lockout *Lockout) Attempt(ctx context.Context, username string) (time.Duration, bool, error) {
+	if lockout.maxFailures <= 0 {
+		return 0, false, nil
+	}
+
+	window, err := lockout.backend.Increment(ctx, lockoutPrefix+username, lockout.duration)
+	if err != nil {
+		return 0, false, err
+	}
+	if window.Hits <= lockout.maxFailures {
+		return 0, false, nil
+	}
+	return time.Until(window.ResetAt), true, nil
+}
+
+// Succeed clears the attempts of the username after it logged in
+func (lockout *Lockout) Succeed(ctx context.Context, username string) error {
+	if lockout.maxFailures <= 0 {
+		return nil
+	}
+	return lockout.backend.Reset(ctx, lockoutPrefix+username)
+}
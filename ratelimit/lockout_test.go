@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuuban007/simplebank/util"
+)
+
+func TestLockout(t *testing.T) {
+	lockout := NewLockout(NewMemoryBackend(), 2, time.Minute)
+	username := util.RandomOwner()
+
+	for i := 0; i < 2; i++ {
+		_, locked, err := lockout.Attempt(context.Background(), username)
+		require.NoError(t, err)
+		require.False(t, locked)
+	}
+
+	retryAfter, locked, err := lockout.Attempt(context.Background(), username)
+	require.NoError(t, err)
+	require.True(t, locked)
+	require.InDelta(t, time.Minute, retryAfter, float64(time.Second))
+
+	// the other usernames aren't locked
+	_, locked, err = lockout.Attempt(context.Background(), util.RandomOwner())
+	require.NoError(t, err)
+	require.False(t, locked)
+}
+
+func TestLockoutParallelAttempts(t *testing.T) {
+	const maxFailures = 3
+	lockout := NewLockout(NewMemoryBackend(), maxFailures, time.Minute)
+	username := util.RandomOwner()
+
+	// parallel guesses can't get more password checks than the lockout allows
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, locked, err := lockout.Attempt(context.Background(), username)
+			require.NoError(t, err)
+			if !locked {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int64(maxFailures), allowed.Load())
+}
+
+func TestLockoutSucceed(t *testing.T) {
+	lockout := NewLockout(NewMemoryBackend(), 2, time.Minute)
+	username := util.RandomOwner()
+
+	_, _, err := lockout.Attempt(context.Background(), username)
+	require.NoError(t, err)
+	_, _, err = lockout.Attempt(context.Background(), username)
+	require.NoError(t, err)
+
+	// a successful login starts the count over
+	err = lockout.Succeed(context.Background(), username)
+	require.NoError(t, err)
+
+	_, locked, err := lockout.Attempt(context.Background(), username)
+	require.NoError(t, err)
+	require.False(t, locked)
+}
+
+func TestLockoutDisabled(t *testing.T) {
+	lockout := NewLockout(NewMemoryBackend(), 0, time.Minute)
+	username := util.RandomOwner()
+
+	for i := 0; i < 10; i++ {
+		_, locked, err := lockout.Attempt(context.Background(), username)
+		require.NoError(t, err)
+		require.False(t, locked)
+	}
+}
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// memoryBackendMaxSize bounds the memory backend, expired windows are swept when it is reached
+// and the new keys are refused while it is still full
+const memoryBackendMaxSize = 100000
+
+// ErrBackendFull is returned for a new key while the memory backend holds memoryBackendMaxSize live windows.
+// Like any backend error it lets the request through, the keys already counted stay limited.
+var ErrBackendFull = errors.New("rate limit backend is full")
+
+// MemoryBackend keeps the hit counts in memory, each instance of the server counts on its own
+type MemoryBackend struct {
+	mu      sync.Mutex
+	windows map[string]Window
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		windows: make(map[string]Window),
+	}
+}
+
+func (backend *MemoryBackend) Increment(ctx context.Context, key string, window time.Duration) (Window, error) {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	now := time.Now()
+	current, ok := backend.windows[key]
+	if !ok || !now.Before(current.ResetAt) {
+		if !ok && len(backend.windows) >= memoryBackendMaxSize {
+			backend.sweep(now)
+			if len(backend.windows) >= memoryBackendMaxSize {
+				return Window{}, ErrBackendFull
+			}
+		}
+		current = Window{ResetAt: now.Add(window)}
+	}
+	current.Hits++
+	backend.windows[key] = current
+	return current, nil
+}
+
+func (backend *MemoryBackend) Reset(ctx context.Context, key string) error {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	delete(backend.windows, key)
+	return nil
+}
+
+func (backend *MemoryBackend) sweep(now time.Time) {
+	for key, window := range backend.windows {
+		if !now.Before(window.ResetAt) {
+			delete(backend.windows, key)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuuban007/simplebank/util"
+)
+
+func TestMemoryBackend(t *testing.T) {
+	backend := NewMemoryBackend()
+	key := util.RandomString(8)
+
+	window1, err := backend.Increment(context.Background(), key, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), window1.Hits)
+	require.WithinDuration(t, time.Now().Add(time.Minute), window1.ResetAt, time.Second)
+
+	// the second hit falls in the window of the first
+	window2, err := backend.Increment(context.Background(), key, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), window2.Hits)
+	require.Equal(t, window1.ResetAt, window2.ResetAt)
+
+	// the hits start over after a reset
+	err = backend.Reset(context.Background(), key)
+	require.NoError(t, err)
+	window, err := backend.Increment(context.Background(), key, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), window.Hits)
+}
+
+func TestMemoryBackendWindowEnds(t *testing.T) {
+	backend := NewMemoryBackend()
+	key := util.RandomString(8)
+
+	_, err := backend.Increment(context.Background(), key, time.Millisecond)
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+
+	// the next hit after the window ended starts a new one
+	window, err := backend.Increment(context.Background(), key, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), window.Hits)
+}
+
+func TestMemoryBackendFull(t *testing.T) {
+	backend := NewMemoryBackend()
+	for i := 0; i < memoryBackendMaxSize; i++ {
+		backend.windows[strconv.Itoa(i)] = Window{Hits: 1, ResetAt: time.Now().Add(time.Minute)}
+	}
+
+	// the live windows are kept and the new keys refused
+	_, err := backend.Increment(context.Background(), "new", time.Minute)
+	require.ErrorIs(t, err, ErrBackendFull)
+	require.Len(t, backend.windows, memoryBackendMaxSize)
+
+	window, err := backend.Increment(context.Background(), "0", time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), window.Hits)
+
+	// an expired window makes room
+	backend.windows["1"] = Window{Hits: 1, ResetAt: time.Now().Add(-time.Second)}
+	window, err = backend.Increment(context.Background(), "new", time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), window.Hits)
+}
+
+func TestMemoryBackendSweep(t *testing.T) {
+	backend := NewMemoryBackend()
+	backend.windows["expired"] = Window{Hits: 1, ResetAt: time.Now().Add(-time.Second)}
+	backend.windows["active"] = Window{Hits: 1, ResetAt: time.Now().Add(time.Minute)}
+
+	backend.sweep(time.Now())
+	require.NotContains(t, backend.windows, "expired")
+	require.Contains(t, backend.windows, "active")
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	db "github.com/yuuban007/simplebank/db/sqlc"
+)
+
+// postgresSweepInterval is how often the expired windows are deleted from postgres
+const postgresSweepInterval = time.Minute
+
+// PostgresBackend keeps the hit counts in postgres, so that every instance of the server shares them
+type PostgresBackend struct {
+	store db.Store
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewPostgresBackend(store db.Store) *PostgresBackend {
+	return &PostgresBackend{
+		store:     store,
+		lastSweep: time.Now(),
+	}
+}
+
+func (backend *PostgresBackend) Increment(ctx context.Context, key string, window time.Duration) (Window, error) {
+	now := time.Now()
+	if err := backend.sweep(ctx, now); err != nil {
+		return Window{}, err
+	}
+
+	rateLimit, err := backend.store.IncrementRateLimit(ctx, db.IncrementRateLimitParams{
+		Key:     key,
+		ResetAt: now.Add(window),
+		Now:     now,
+	})
+	if err != nil {
+		return Window{}, err
+	}
+	return Window{Hits: rateLimit.Hits, ResetAt: rateLimit.ResetAt}, nil
+}
+
+func (backend *PostgresBackend) Reset(ctx context.Context, key string) error {
+	return backend.store.DeleteRateLimit(ctx, key)
+}
+
+// sweep deletes the expired windows once in a while, the keys of clients that went away would pile up otherwise
+func (backend *PostgresBackend) sweep(ctx context.Context, now time.Time) error {
+	backend.mu.Lock()
+	if now.Sub(backend.lastSweep) < postgresSweepInterval {
+		backend.mu.Unlock()
+		return nil
+	}
+	backend.lastSweep = now
+	backend.mu.Unlock()
+
+	return backend.store.DeleteExpiredRateLimits(ctx, now)
+}
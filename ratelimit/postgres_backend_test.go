@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/yuuban007/simplebank/db/mock"
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/util"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPostgresBackend(t *testing.T) {
+	store := mockdb.NewMockStore(gomock.NewController(t))
+	backend := NewPostgresBackend(store)
+	key := util.RandomString(8)
+	resetAt := time.Now().Add(time.Minute)
+
+	store.EXPECT().
+		IncrementRateLimit(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.IncrementRateLimitParams) (db.RateLimit, error) {
+			require.Equal(t, key, arg.Key)
+			require.WithinDuration(t, arg.Now.Add(time.Minute), arg.ResetAt, 0)
+			return db.RateLimit{Key: key, Hits: 3, ResetAt: resetAt}, nil
+		})
+	window, err := backend.Increment(context.Background(), key, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, Window{Hits: 3, ResetAt: resetAt}, window)
+
+	store.EXPECT().
+		DeleteRateLimit(gomock.Any(), gomock.Eq(key)).
+		Times(1).
+		Return(nil)
+	err = backend.Reset(context.Background(), key)
+	require.NoError(t, err)
+}
+
+func TestPostgresBackendSweep(t *testing.T) {
+	store := mockdb.NewMockStore(gomock.NewController(t))
+	backend := NewPostgresBackend(store)
+	backend.lastSweep = time.Now().Add(-postgresSweepInterval)
+
+	// the first increment after the interval deletes the expired windows, the next one doesn't
+	store.EXPECT().
+		DeleteExpiredRateLimits(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil)
+	store.EXPECT().
+		IncrementRateLimit(gomock.Any(), gomock.Any()).
+		Times(2).
+		Return(db.RateLimit{Hits: 1, ResetAt: time.Now().Add(time.Minute)}, nil)
+
+	for i := 0; i < 2; i++ {
+		_, err := backend.Increment(context.Background(), util.RandomString(8), time.Minute)
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,68 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	db "github.com/yuuban007/simplebank/db/sqlc"
+)
+
+var csvColumns = []string{
+	"date",
+	"entry_id",
+	"description",
+	"transfer_id",
+	"counterparty_account_id",
+	"counterparty_owner",
+	"amount",
+	"balance",
+	"currency",
+}
+
+// CSVWriter renders a statement as CSV, one row per entry
+type CSVWriter struct {
+	w        *csv.Writer
+	currency string
+}
+
+// NewCSVWriter creates a new CSVWriter
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w)}
+}
+
+// WriteHeader implements db.StatementWriter
+func (writer *CSVWriter) WriteHeader(header db.StatementHeader) error {
+	writer.currency = header.Account.Currency
+	return writer.w.Write(csvColumns)
+}
+
+// WriteLine implements db.StatementWriter
+func (writer *CSVWriter) WriteLine(line db.StatementLine) error {
+	var transferID, counterpartyAccountID string
+	if line.TransferID != nil {
+		transferID = strconv.FormatInt(*line.TransferID, 10)
+	}
+	if line.CounterpartyAccountID.Valid {
+		counterpartyAccountID = strconv.FormatInt(line.CounterpartyAccountID.Int64, 10)
+	}
+
+	return writer.w.Write([]string{
+		line.CreatedAt.UTC().Format(time.RFC3339),
+		strconv.FormatInt(line.ID, 10),
+		describe(line),
+		transferID,
+		counterpartyAccountID,
+		line.CounterpartyOwner.String,
+		formatAmount(line.Amount),
+		formatAmount(line.Balance),
+		writer.currency,
+	})
+}
+
+// WriteFooter implements db.StatementWriter
+func (writer *CSVWriter) WriteFooter(closingBalance int64) error {
+	writer.w.Flush()
+	return writer.w.Error()
+}
@@ -0,0 +1,27 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVWriter(t *testing.T) {
+	header, lines := randomStatement(t)
+
+	var buffer bytes.Buffer
+	writeStatement(t, NewCSVWriter(&buffer), header, lines)
+
+	records, err := csv.NewReader(&buffer).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.Equal(t, csvColumns, records[0])
+	require.Equal(t, []string{
+		"2024-01-10T12:30:00Z", "1", "Transfer to account 42 (alice)", records[1][3], "42", "alice", "-25.50", "74.50", "USD",
+	}, records[1])
+	require.Equal(t, []string{
+		"2024-01-20T09:00:00Z", "2", "Cash deposit", "", "", "", "0.05", "74.55", "USD",
+	}, records[2])
+}
@@ -0,0 +1,90 @@
+package statement
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+
+	db "github.com/yuuban007/simplebank/db/sqlc"
+)
+
+// ofxBankID identifies the bank in the BANKACCTFROM aggregate
+const ofxBankID = "SIMPLEBANK"
+
+// ofxMaxNameLength is the limit of the NAME element of a transaction
+const ofxMaxNameLength = 32
+
+// OFXWriter renders a statement as an OFX 2.2 bank statement response
+type OFXWriter struct {
+	w      *countingWriter
+	header db.StatementHeader
+}
+
+// NewOFXWriter creates a new OFXWriter
+func NewOFXWriter(w io.Writer) *OFXWriter {
+	return &OFXWriter{w: &countingWriter{w: w}}
+}
+
+// WriteHeader implements db.StatementWriter
+func (writer *OFXWriter) WriteHeader(header db.StatementHeader) error {
+	writer.header = header
+	w := writer.w
+	w.printf("<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"no\"?>\n")
+	w.printf("<?OFX OFXHEADER=\"200\" VERSION=\"220\" SECURITY=\"NONE\" OLDFILEUID=\"NONE\" NEWFILEUID=\"NONE\"?>\n")
+	w.printf("<OFX>\n")
+	w.printf("<SIGNONMSGSRSV1><SONRS>")
+	w.printf("<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>")
+	w.printf("<DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE>", ofxTime(time.Now()))
+	w.printf("</SONRS></SIGNONMSGSRSV1>\n")
+	w.printf("<BANKMSGSRSV1><STMTTRNRS><TRNUID>0</TRNUID>")
+	w.printf("<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n")
+	w.printf("<STMTRS><CURDEF>%s</CURDEF>\n", header.Account.Currency)
+	w.printf("<BANKACCTFROM><BANKID>%s</BANKID><ACCTID>%d</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>\n",
+		ofxBankID, header.Account.ID)
+	w.printf("<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>\n", ofxTime(header.From), ofxTime(header.To))
+	return w.err
+}
+
+// WriteLine implements db.StatementWriter
+func (writer *OFXWriter) WriteLine(line db.StatementLine) error {
+	trnType := "XFER"
+	name := line.CounterpartyOwner.String
+	if line.TransferID == nil {
+		trnType = "DEP"
+		if line.Amount < 0 {
+			trnType = "CASH"
+		}
+		name = describe(line)
+	}
+	if len(name) > ofxMaxNameLength {
+		name = name[:ofxMaxNameLength]
+	}
+
+	w := writer.w
+	w.printf("<STMTTRN><TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%s</TRNAMT><FITID>%d</FITID>",
+		trnType, ofxTime(line.CreatedAt), formatAmount(line.Amount), line.ID)
+	w.printf("<NAME>%s</NAME><MEMO>%s</MEMO></STMTTRN>\n", ofxEscape(name), ofxEscape(describe(line)))
+	return w.err
+}
+
+// WriteFooter implements db.StatementWriter
+func (writer *OFXWriter) WriteFooter(closingBalance int64) error {
+	w := writer.w
+	w.printf("</BANKTRANLIST>\n")
+	w.printf("<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>\n",
+		formatAmount(closingBalance), ofxTime(writer.header.To))
+	w.printf("</STMTRS></STMTTRNRS></BANKMSGSRSV1>\n")
+	w.printf("</OFX>\n")
+	return w.err
+}
+
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:GMT]"
+}
+
+func ofxEscape(s string) string {
+	var builder strings.Builder
+	xml.EscapeText(&builder, []byte(s))
+	return builder.String()
+}
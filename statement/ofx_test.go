@@ -0,0 +1,60 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type ofxDocument struct {
+	XMLName xml.Name `xml:"OFX"`
+	StmtRs  struct {
+		CurDef       string `xml:"CURDEF"`
+		BankAcctFrom struct {
+			AcctID string `xml:"ACCTID"`
+		} `xml:"BANKACCTFROM"`
+		BankTranList struct {
+			DtStart      string `xml:"DTSTART"`
+			Transactions []struct {
+				TrnType string `xml:"TRNTYPE"`
+				TrnAmt  string `xml:"TRNAMT"`
+				FitID   string `xml:"FITID"`
+				Name    string `xml:"NAME"`
+			} `xml:"STMTTRN"`
+		} `xml:"BANKTRANLIST"`
+		LedgerBal struct {
+			BalAmt string `xml:"BALAMT"`
+		} `xml:"LEDGERBAL"`
+	} `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS"`
+}
+
+func TestOFXWriter(t *testing.T) {
+	header, lines := randomStatement(t)
+	header.Account.Owner = "<tom & jerry>"
+
+	var buffer bytes.Buffer
+	writeStatement(t, NewOFXWriter(&buffer), header, lines)
+	require.Contains(t, buffer.String(), `<?OFX OFXHEADER="200" VERSION="220"`)
+
+	var document ofxDocument
+	err := xml.Unmarshal(buffer.Bytes(), &document)
+	require.NoError(t, err)
+
+	stmtRs := document.StmtRs
+	require.Equal(t, "USD", stmtRs.CurDef)
+	require.Equal(t, fmt.Sprint(header.Account.ID), stmtRs.BankAcctFrom.AcctID)
+	require.Equal(t, "20240101000000.000[0:GMT]", stmtRs.BankTranList.DtStart)
+	require.Equal(t, "74.55", stmtRs.LedgerBal.BalAmt)
+
+	transactions := stmtRs.BankTranList.Transactions
+	require.Len(t, transactions, 2)
+	require.Equal(t, "XFER", transactions[0].TrnType)
+	require.Equal(t, "-25.50", transactions[0].TrnAmt)
+	require.Equal(t, "1", transactions[0].FitID)
+	require.Equal(t, "alice", transactions[0].Name)
+	require.Equal(t, "DEP", transactions[1].TrnType)
+	require.Equal(t, "0.05", transactions[1].TrnAmt)
+}
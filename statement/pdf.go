@@ -0,0 +1,186 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	db "github.com/yuuban007/simplebank/db/sqlc"
+)
+
+// Layout of the A4 pages, in points
+const (
+	pdfPageWidth    = 595
+	pdfPageHeight   = 842
+	pdfMargin       = 50
+	pdfFontSize     = 9
+	pdfLeading      = 13
+	pdfLinesPerPage = 56
+)
+
+// The catalog, page tree and font are given fixed object numbers,
+// the page tree is written last when all of its pages are known
+const (
+	pdfCatalogID = 1
+	pdfPagesID   = 2
+	pdfFontID    = 3
+)
+
+// PDFWriter renders a statement as a plain text PDF document.
+// Pages are written as soon as they are full, only the current page is held in memory.
+type PDFWriter struct {
+	w        *countingWriter
+	currency string
+	// offsets[id] is the byte offset of object id
+	offsets []int64
+	pageIDs []int
+	page    bytes.Buffer
+	lines   int
+}
+
+// NewPDFWriter creates a new PDFWriter
+func NewPDFWriter(w io.Writer) *PDFWriter {
+	return &PDFWriter{
+		w:       &countingWriter{w: w},
+		offsets: make([]int64, pdfFontID+1),
+	}
+}
+
+// WriteHeader implements db.StatementWriter
+func (writer *PDFWriter) WriteHeader(header db.StatementHeader) error {
+	writer.currency = header.Account.Currency
+
+	// the binary comment marks the file as binary for transfer programs
+	writer.w.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+	writer.beginObject(pdfFontID)
+	writer.w.printf("<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>\n")
+	writer.endObject()
+
+	writer.addText(fmt.Sprintf("Statement of account %d (%s)", header.Account.ID, header.Account.Owner))
+	writer.addText(fmt.Sprintf("Period: %s to %s",
+		header.From.UTC().Format("2006-01-02 15:04:05"), header.To.UTC().Format("2006-01-02 15:04:05")))
+	writer.addText(fmt.Sprintf("Currency: %s", header.Account.Currency))
+	writer.addText("")
+	writer.addText(pdfRow("Date", "Entry", "Description", "Amount", "Balance"))
+	writer.addText(pdfRow("", "", "Opening balance", "", formatAmount(header.OpeningBalance)))
+	return writer.w.err
+}
+
+// WriteLine implements db.StatementWriter
+func (writer *PDFWriter) WriteLine(line db.StatementLine) error {
+	writer.addText(pdfRow(
+		line.CreatedAt.UTC().Format("2006-01-02 15:04"),
+		fmt.Sprint(line.ID),
+		describe(line),
+		formatAmount(line.Amount),
+		formatAmount(line.Balance),
+	))
+	return writer.w.err
+}
+
+// WriteFooter implements db.StatementWriter
+func (writer *PDFWriter) WriteFooter(closingBalance int64) error {
+	writer.addText(pdfRow("", "", "Closing balance", "", formatAmount(closingBalance)))
+	writer.flushPage()
+
+	writer.beginObject(pdfPagesID)
+	kids := make([]string, len(writer.pageIDs))
+	for i, id := range writer.pageIDs {
+		kids[i] = fmt.Sprintf("%d 0 R", id)
+	}
+	writer.w.printf("<< /Type /Pages /Kids [%s] /Count %d >>\n", strings.Join(kids, " "), len(kids))
+	writer.endObject()
+
+	writer.beginObject(pdfCatalogID)
+	writer.w.printf("<< /Type /Catalog /Pages %d 0 R >>\n", pdfPagesID)
+	writer.endObject()
+
+	xrefOffset := writer.w.n
+	writer.w.printf("xref\n0 %d\n", len(writer.offsets))
+	writer.w.printf("%010d 65535 f \n", 0)
+	for _, offset := range writer.offsets[1:] {
+		writer.w.printf("%010d 00000 n \n", offset)
+	}
+	writer.w.printf("trailer\n<< /Size %d /Root %d 0 R >>\n", len(writer.offsets), pdfCatalogID)
+	writer.w.printf("startxref\n%d\n%%%%EOF\n", xrefOffset)
+	return writer.w.err
+}
+
+// addText adds a line of text to the current page, writing the page out when it is full
+func (writer *PDFWriter) addText(text string) {
+	if writer.lines == pdfLinesPerPage {
+		writer.flushPage()
+	}
+	if writer.lines == 0 {
+		fmt.Fprintf(&writer.page, "BT /F1 %d Tf %d TL %d %d Td\n",
+			pdfFontSize, pdfLeading, pdfMargin, pdfPageHeight-pdfMargin)
+	}
+	fmt.Fprintf(&writer.page, "(%s) Tj T*\n", pdfEscape(text))
+	writer.lines++
+}
+
+// flushPage writes the content stream and the page object of the current page
+func (writer *PDFWriter) flushPage() {
+	if writer.lines == 0 {
+		return
+	}
+	writer.page.WriteString("ET\n")
+
+	contentID := writer.newObject()
+	writer.beginObject(contentID)
+	writer.w.printf("<< /Length %d >>\nstream\n", writer.page.Len())
+	writer.w.Write(writer.page.Bytes())
+	writer.w.printf("endstream\n")
+	writer.endObject()
+
+	pageID := writer.newObject()
+	writer.beginObject(pageID)
+	writer.w.printf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>\n",
+		pdfPagesID, pdfPageWidth, pdfPageHeight, pdfFontID, contentID)
+	writer.endObject()
+
+	writer.pageIDs = append(writer.pageIDs, pageID)
+	writer.page.Reset()
+	writer.lines = 0
+}
+
+// newObject reserves the next object number
+func (writer *PDFWriter) newObject() int {
+	writer.offsets = append(writer.offsets, 0)
+	return len(writer.offsets) - 1
+}
+
+func (writer *PDFWriter) beginObject(id int) {
+	writer.offsets[id] = writer.w.n
+	writer.w.printf("%d 0 obj\n", id)
+}
+
+func (writer *PDFWriter) endObject() {
+	writer.w.printf("endobj\n")
+}
+
+// pdfRow lays out the columns of a statement row for the monospaced font
+func pdfRow(date string, entry string, description string, amount string, balance string) string {
+	if len(description) > 40 {
+		description = description[:37] + "..."
+	}
+	return fmt.Sprintf("%-16s %8s  %-40s %12s %12s", date, entry, description, amount, balance)
+}
+
+// pdfEscape escapes a string literal, replacing what the standard font can't show
+func pdfEscape(s string) string {
+	var builder strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			builder.WriteByte('\\')
+			builder.WriteRune(r)
+		case r < 32 || r > 126:
+			builder.WriteByte('?')
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,64 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	db "github.com/yuuban007/simplebank/db/sqlc"
+)
+
+// requireValidXref checks that every object of the xref table starts at its recorded offset
+func requireValidXref(t *testing.T, document []byte) {
+	match := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(document)
+	require.NotNil(t, match)
+	xrefOffset, err := strconv.Atoi(string(match[1]))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(document[xrefOffset:], []byte("xref\n")))
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n \n`).FindAllSubmatch(document[xrefOffset:], -1)
+	require.NotEmpty(t, entries)
+	for i, entry := range entries {
+		offset, err := strconv.Atoi(string(entry[1]))
+		require.NoError(t, err)
+		require.True(t, bytes.HasPrefix(document[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))))
+	}
+}
+
+func TestPDFWriter(t *testing.T) {
+	header, lines := randomStatement(t)
+
+	var buffer bytes.Buffer
+	writeStatement(t, NewPDFWriter(&buffer), header, lines)
+
+	document := buffer.Bytes()
+	require.True(t, bytes.HasPrefix(document, []byte("%PDF-1.4\n")))
+	require.Contains(t, buffer.String(), "/Count 1")
+	require.Contains(t, buffer.String(), `Transfer to account 42 \(alice\)`)
+	requireValidXref(t, document)
+}
+
+func TestPDFWriterPages(t *testing.T) {
+	header, lines := randomStatement(t)
+
+	var buffer bytes.Buffer
+	w := NewPDFWriter(&buffer)
+
+	// enough lines for three pages with the header lines
+	var many []db.StatementLine
+	for i := 0; i < 2*pdfLinesPerPage; i++ {
+		many = append(many, lines[i%len(lines)])
+	}
+	writeStatement(t, w, header, many)
+
+	require.Contains(t, buffer.String(), "/Count 3")
+	requireValidXref(t, buffer.Bytes())
+}
+
+func TestPDFEscape(t *testing.T) {
+	require.Equal(t, `a\(b\)\\c`, pdfEscape(`a(b)\c`))
+	require.Equal(t, "caf?", pdfEscape("café"))
+}
@@ -0,0 +1,88 @@
+package statement
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	db "github.com/yuuban007/simplebank/db/sqlc"
+)
+
+// Constants for all supported export formats
+const (
+	CSV = "csv"
+	OFX = "ofx"
+	PDF = "pdf"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported statement format")
+
+// ContentTypes maps the export formats to the media types they are served as
+var ContentTypes = map[string]string{
+	CSV: "text/csv",
+	OFX: "application/x-ofx",
+	PDF: "application/pdf",
+}
+
+// NewWriter creates a db.StatementWriter rendering the statement in the format to w
+func NewWriter(format string, w io.Writer) (db.StatementWriter, error) {
+	switch format {
+	case CSV:
+		return NewCSVWriter(w), nil
+	case OFX:
+		return NewOFXWriter(w), nil
+	case PDF:
+		return NewPDFWriter(w), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+}
+
+// formatAmount formats an amount in minor units like 12345 as 123.45
+func formatAmount(amount int64) string {
+	sign := ""
+	abs := uint64(amount)
+	if amount < 0 {
+		sign = "-"
+		abs = uint64(-amount)
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, abs/100, abs%100)
+}
+
+// describe returns a human readable description of a statement line
+func describe(line db.StatementLine) string {
+	if line.TransferID == nil {
+		if line.Amount < 0 {
+			return "Cash withdrawal"
+		}
+		return "Cash deposit"
+	}
+	if !line.CounterpartyAccountID.Valid {
+		return fmt.Sprintf("Transfer %d", *line.TransferID)
+	}
+	if line.Amount < 0 {
+		return fmt.Sprintf("Transfer to account %d (%s)", line.CounterpartyAccountID.Int64, line.CounterpartyOwner.String)
+	}
+	return fmt.Sprintf("Transfer from account %d (%s)", line.CounterpartyAccountID.Int64, line.CounterpartyOwner.String)
+}
+
+// countingWriter counts the bytes written and keeps the first error,
+// so that a renderer only needs to check for errors once per statement line
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
+
+func (cw *countingWriter) printf(format string, args ...any) {
+	fmt.Fprintf(cw, format, args...)
+}
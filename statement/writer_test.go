@@ -0,0 +1,86 @@
+package statement
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	db "github.com/yuuban007/simplebank/db/sqlc"
+	"github.com/yuuban007/simplebank/util"
+)
+
+// randomStatement returns the header and the lines of a statement with a transfer and a cash deposit
+func randomStatement(t *testing.T) (db.StatementHeader, []db.StatementLine) {
+	account := db.Account{
+		ID:       util.RandomInt(1, 1000),
+		Owner:    util.RandomOwner(),
+		Currency: util.USD,
+	}
+	header := db.StatementHeader{
+		Account:        account,
+		From:           time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:             time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		OpeningBalance: 10000,
+	}
+
+	transferID := util.RandomInt(1, 1000)
+	lines := []db.StatementLine{
+		{
+			ListStatementEntriesRow: db.ListStatementEntriesRow{
+				ID:                    1,
+				Amount:                -2550,
+				TransferID:            &transferID,
+				CounterpartyAccountID: pgtype.Int8{Int64: 42, Valid: true},
+				CounterpartyOwner:     pgtype.Text{String: "alice", Valid: true},
+				CreatedAt:             time.Date(2024, 1, 10, 12, 30, 0, 0, time.UTC),
+			},
+			Balance: 7450,
+		},
+		{
+			ListStatementEntriesRow: db.ListStatementEntriesRow{
+				ID:        2,
+				Amount:    5,
+				CreatedAt: time.Date(2024, 1, 20, 9, 0, 0, 0, time.UTC),
+			},
+			Balance: 7455,
+		},
+	}
+	return header, lines
+}
+
+func writeStatement(t *testing.T, w db.StatementWriter, header db.StatementHeader, lines []db.StatementLine) {
+	require.NoError(t, w.WriteHeader(header))
+	for _, line := range lines {
+		require.NoError(t, w.WriteLine(line))
+	}
+	require.NoError(t, w.WriteFooter(lines[len(lines)-1].Balance))
+}
+
+func TestNewWriter(t *testing.T) {
+	for format := range ContentTypes {
+		w, err := NewWriter(format, &bytes.Buffer{})
+		require.NoError(t, err)
+		require.NotNil(t, w)
+	}
+
+	_, err := NewWriter("xls", &bytes.Buffer{})
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestFormatAmount(t *testing.T) {
+	require.Equal(t, "0.00", formatAmount(0))
+	require.Equal(t, "0.05", formatAmount(5))
+	require.Equal(t, "123.45", formatAmount(12345))
+	require.Equal(t, "-25.50", formatAmount(-2550))
+}
+
+func TestDescribe(t *testing.T) {
+	_, lines := randomStatement(t)
+	require.Equal(t, "Transfer to account 42 (alice)", describe(lines[0]))
+	require.Equal(t, "Cash deposit", describe(lines[1]))
+
+	lines[1].Amount = -lines[1].Amount
+	require.Equal(t, "Cash withdrawal", describe(lines[1]))
+}
@@ -0,0 +1,134 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+
+	"github.com/yuuban007/simplebank/util"
+)
+
+// Constants for all supported token types
+const (
+	PasetoLocalType  = "paseto-local"
+	PasetoPublicType = "paseto-public"
+	JWTHMACType      = "jwt-hmac"
+	JWTEdDSAType     = "jwt-eddsa"
+	JWTRS256Type     = "jwt-rs256"
+)
+
+var ErrUnsupportedTokenType = errors.New("unsupported token type")
+
+// NewMaker builds the Maker selected by TOKEN_TYPE, PASETO local tokens are used by default
+func NewMaker(config util.Config) (Maker, error) {
+	switch config.TokenType {
+	case "", PasetoLocalType:
+		keyring, err := newConfigKeyring(config)
+		if err != nil {
+			return nil, err
+		}
+		return NewPasetoKeyringMaker(keyring)
+	case JWTHMACType:
+		keyring, err := newConfigKeyring(config)
+		if err != nil {
+			return nil, err
+		}
+		return NewJWTKeyringMaker(keyring)
+	case PasetoPublicType:
+		privateKey, err := loadConfigEd25519Key(config)
+		if err != nil {
+			return nil, err
+		}
+		retiredKeys, err := loadConfigRetiredKeys(config)
+		if err != nil {
+			return nil, err
+		}
+		return NewPasetoPublicMaker(config.TokenKeyID, privateKey, retiredKeys)
+	case JWTEdDSAType:
+		privateKey, err := loadConfigEd25519Key(config)
+		if err != nil {
+			return nil, err
+		}
+		retiredKeys, err := loadConfigRetiredKeys(config)
+		if err != nil {
+			return nil, err
+		}
+		return NewJWTPublicMaker(config.TokenKeyID, privateKey, retiredKeys)
+	case JWTRS256Type:
+		privateKey, err := loadConfigRSAKey(config)
+		if err != nil {
+			return nil, err
+		}
+		retiredKeys, err := loadConfigRetiredKeys(config)
+		if err != nil {
+			return nil, err
+		}
+		return NewJWTPublicMaker(config.TokenKeyID, privateKey, retiredKeys)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedTokenType, config.TokenType)
+}
+
+func newConfigKeyring(config util.Config) (*Keyring, error) {
+	if config.TokenSymmetricKey == "" {
+		return nil, fmt.Errorf("token type %s requires TOKEN_SYMMETRIC_KEY", config.TokenType)
+	}
+	retiredKeys, err := config.RetiredTokenKeys()
+	if err != nil {
+		return nil, err
+	}
+	return NewKeyring(config.TokenKeyID, config.TokenSymmetricKey, retiredKeys)
+}
+
+func loadConfigPrivateKey(config util.Config) (crypto.Signer, error) {
+	if config.TokenPrivateKeyPath == "" {
+		return nil, fmt.Errorf("token type %s requires TOKEN_PRIVATE_KEY_PATH", config.TokenType)
+	}
+	return LoadPrivateKey(config.TokenPrivateKeyPath)
+}
+
+func loadConfigEd25519Key(config util.Config) (ed25519.PrivateKey, error) {
+	privateKey, err := loadConfigPrivateKey(config)
+	if err != nil {
+		return nil, err
+	}
+	ed25519Key, ok := privateKey.(ed25519.PrivateKey)
+	if !ok || len(ed25519Key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("token type %s requires an Ed25519 private key", config.TokenType)
+	}
+	return ed25519Key, nil
+}
+
+func loadConfigRSAKey(config util.Config) (*rsa.PrivateKey, error) {
+	privateKey, err := loadConfigPrivateKey(config)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := privateKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("token type %s requires an RSA private key", config.TokenType)
+	}
+	if rsaKey.N.BitLen() < minRSAKeyBits {
+		return nil, ErrRSAKeyTooShort
+	}
+	return rsaKey, nil
+}
+
+// loadConfigRetiredKeys loads the public keys of TOKEN_RETIRED_KEY_PATHS
+func loadConfigRetiredKeys(config util.Config) (map[string]crypto.PublicKey, error) {
+	paths, err := config.RetiredTokenKeyPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(paths))
+	for keyID, path := range paths {
+		key, err := LoadPublicKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("retired key %s: %w", keyID, err)
+		}
+		keys[keyID] = key
+	}
+	return keys, nil
+}
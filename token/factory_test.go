@@ -0,0 +1,206 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuuban007/simplebank/util"
+)
+
+func writePrivateKey(t *testing.T, privateKey any) string {
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "token_key.pem")
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+	require.NoError(t, err)
+	return path
+}
+
+func writePublicKey(t *testing.T, publicKey any) string {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "token_key.pub.pem")
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600)
+	require.NoError(t, err)
+	return path
+}
+
+func TestNewMaker(t *testing.T) {
+	ed25519KeyPath := writePrivateKey(t, randomEd25519Key(t))
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaKeyPath := writePrivateKey(t, rsaKey)
+	shortRSAKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	shortRSAKeyPath := writePrivateKey(t, shortRSAKey)
+	retiredEd25519KeyPath := writePublicKey(t, randomEd25519Key(t).Public())
+
+	testCases := []struct {
+		name      string
+		config    util.Config
+		checkType func(t *testing.T, maker Maker, err error)
+	}{
+		{
+			name:   "DefaultPasetoLocal",
+			config: util.Config{TokenSymmetricKey: util.RandomString(32)},
+			checkType: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &PasetoMaker{}, maker)
+			},
+		},
+		{
+			name:   "PasetoLocalInvalidKeySize",
+			config: util.Config{TokenType: PasetoLocalType, TokenSymmetricKey: util.RandomString(33)},
+			checkType: func(t *testing.T, maker Maker, err error) {
+				require.Error(t, err)
+				require.Nil(t, maker)
+			},
+		},
+		{
+			name:   "JWTHMAC",
+			config: util.Config{TokenType: JWTHMACType, TokenSymmetricKey: util.RandomString(40)},
+			checkType: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &JWTMaker{}, maker)
+			},
+		},
+		{
+			name:   "JWTHMACShortKey",
+			config: util.Config{TokenType: JWTHMACType, TokenSymmetricKey: util.RandomString(16)},
+			checkType: func(t *testing.T, maker Maker, err error) {
+				require.ErrorIs(t, err, ErrSecretKeyTooShort)
+				require.Nil(t, maker)
+			},
+		},
+		{
+			name:   "PasetoPublic",
+			config: util.Config{TokenType: PasetoPublicType, TokenPrivateKeyPath: ed25519KeyPath},
+			checkType: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &PasetoPublicMaker{}, maker)
+			},
+		},
+		{
+			name:   "JWTEdDSA",
+			config: util.Config{TokenType: JWTEdDSAType, TokenPrivateKeyPath: ed25519KeyPath},
+			checkType: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &JWTPublicMaker{}, maker)
+			},
+		},
+		{
+			name:   "JWTEdDSAWithRSAKey",
+			config: util.Config{TokenType: JWTEdDSAType, TokenPrivateKeyPath: rsaKeyPath},
+			checkType: func(t *testing.T, maker Maker, err error) {
+				require.Error(t, err)
+				require.Nil(t, maker)
+			},
+		},
+		{
+			name:   "JWTRS256",
+			config: util.Config{TokenType: JWTRS256Type, TokenPrivateKeyPath: rsaKeyPath},
+			checkType: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &JWTPublicMaker{}, maker)
+				require.Equal(t, "RS256", maker.(*JWTPublicMaker).method.Alg())
+			},
+		},
+		{
+			name:   "JWTRS256ShortKey",
+			config: util.Config{TokenType: JWTRS256Type, TokenPrivateKeyPath: shortRSAKeyPath},
+			checkType: func(t *testing.T, maker Maker, err error) {
+				require.ErrorIs(t, err, ErrRSAKeyTooShort)
+				require.Nil(t, maker)
+			},
+		},
+		{
+			name:   "JWTRS256WithEd25519Key",
+			config: util.Config{TokenType: JWTRS256Type, TokenPrivateKeyPath: ed25519KeyPath},
+			checkType: func(t *testing.T, maker Maker, err error) {
+				require.Error(t, err)
+				require.Nil(t, maker)
+			},
+		},
+		{
+			name: "PasetoPublicRetiredKey",
+			config: util.Config{
+				TokenType:            PasetoPublicType,
+				TokenKeyID:           "new",
+				TokenPrivateKeyPath:  ed25519KeyPath,
+				TokenRetiredKeyPaths: "old:" + retiredEd25519KeyPath,
+			},
+			checkType: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.Len(t, maker.(PublicKeyMaker).PublicKeys().Keys, 2)
+			},
+		},
+		{
+			name: "JWTEdDSARetiredKey",
+			config: util.Config{
+				TokenType:            JWTEdDSAType,
+				TokenKeyID:           "new",
+				TokenPrivateKeyPath:  ed25519KeyPath,
+				TokenRetiredKeyPaths: "old:" + retiredEd25519KeyPath,
+			},
+			checkType: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.Len(t, maker.(PublicKeyMaker).PublicKeys().Keys, 2)
+			},
+		},
+		{
+			name: "RetiredKeyMissingFile",
+			config: util.Config{
+				TokenType:            PasetoPublicType,
+				TokenKeyID:           "new",
+				TokenPrivateKeyPath:  ed25519KeyPath,
+				TokenRetiredKeyPaths: "old:" + filepath.Join(t.TempDir(), "missing.pem"),
+			},
+			checkType: func(t *testing.T, maker Maker, err error) {
+				require.Error(t, err)
+				require.Nil(t, maker)
+			},
+		},
+		{
+			name:   "PasetoPublicMissingKey",
+			config: util.Config{TokenType: PasetoPublicType},
+			checkType: func(t *testing.T, maker Maker, err error) {
+				require.Error(t, err)
+				require.Nil(t, maker)
+			},
+		},
+		{
+			name:   "UnsupportedType",
+			config: util.Config{TokenType: "jwt-none", TokenSymmetricKey: util.RandomString(32)},
+			checkType: func(t *testing.T, maker Maker, err error) {
+				require.ErrorIs(t, err, ErrUnsupportedTokenType)
+				require.Nil(t, maker)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			maker, err := NewMaker(tc.config)
+			tc.checkType(t, maker, err)
+			if err != nil {
+				return
+			}
+
+			token, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, AccessToken, time.Minute)
+			require.NoError(t, err)
+			_, err = maker.VerifyToken(token, AccessToken)
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// JSONWebKey is the public part of a signing key in the JWK format (RFC 7517)
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	KeyID     string `json:"kid,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JSONWebKeySet is a set of public keys that verify tokens
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// PublicKeyMaker is a Maker whose tokens can be verified with public keys only
+type PublicKeyMaker interface {
+	Maker
+
+	// PublicKeys returns the public keys that verify the tokens of the maker
+	PublicKeys() JSONWebKeySet
+}
+
+func newJSONWebKey(keyID string, algorithm string, publicKey crypto.PublicKey) JSONWebKey {
+	jwk := JSONWebKey{
+		Use:       "sig",
+		KeyID:     keyID,
+		Algorithm: algorithm,
+	}
+	switch key := publicKey.(type) {
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(key)
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	}
+	return jwk
+}
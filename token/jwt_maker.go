@@ -16,50 +16,87 @@ var (
 
 // JWTMaker is a Json Web Token maker
 type JWTMaker struct {
-	secretKey string
+	keyring *Keyring
 }
 
 // NewJWTMaker returns a new JWTMaker
 func NewJWTMaker(secretKey string) (Maker, error) {
-	if len(secretKey) < minServerSecretKeySize {
-		return nil, ErrSecretKeyTooShort
+	keyring, err := NewKeyring("", secretKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	return NewJWTKeyringMaker(keyring)
+}
+
+// NewJWTKeyringMaker returns a JWTMaker that supports key rotation
+func NewJWTKeyringMaker(keyring *Keyring) (Maker, error) {
+	err := keyring.validate(func(key []byte) error {
+		if len(key) < minServerSecretKeySize {
+			return ErrSecretKeyTooShort
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return &JWTMaker{secretKey}, nil
+	return &JWTMaker{keyring}, nil
 }
 
 // CreateToken implements Maker.
-func (maker *JWTMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	Payload, err := NewPayload(username, duration)
+func (maker *JWTMaker) CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, tokenType, duration)
 	if err != nil {
-		return "", err
+		return "", payload, err
 	}
 	// SigningMethodHS256
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, Payload)
-	return jwtToken.SignedString([]byte(maker.secretKey))
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
+
+	keyID, key := maker.keyring.activeKey()
+	if keyID != "" {
+		jwtToken.Header["kid"] = keyID
+	}
+	token, err := jwtToken.SignedString(key)
+	return token, payload, err
 }
 
 // VerifyToken implements Maker.
-func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
+func (maker *JWTMaker) VerifyToken(token string, tokenType TokenType) (*Payload, error) {
+	var keys [][]byte
 	keyFunc := func(token *jwt.Token) (interface{}, error) {
 		_, ok := token.Method.(*jwt.SigningMethodHMAC)
 		if !ok {
 			return nil, ErrInvalidToken
 		}
 		// if the convert success, it meaning the alg match
-		return []byte(maker.secretKey), nil
+		if keys == nil {
+			keyID, _ := token.Header["kid"].(string)
+			var err error
+			keys, err = maker.keyring.verificationKeys(keyID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return keys[0], nil
 	}
 
-	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
-	if err != nil {
-		vErr, ok := err.(*jwt.ValidationError)
-		if ok && errors.Is(vErr.Inner, ErrExpiredToken) {
-			return nil, ErrExpiredToken
+	for {
+		jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
+		if err != nil {
+			vErr, ok := err.(*jwt.ValidationError)
+			// try the next candidate key if the signature didn't match
+			if ok && vErr.Errors&jwt.ValidationErrorSignatureInvalid != 0 && len(keys) > 1 {
+				keys = keys[1:]
+				continue
+			}
+			if ok && errors.Is(vErr.Inner, ErrExpiredToken) {
+				return nil, ErrExpiredToken
+			}
+			return nil, ErrInvalidToken
 		}
-		return nil, ErrInvalidToken
-	}
-	payload, ok := jwtToken.Claims.(*Payload)
-	if !ok {
-		return nil, ErrInvalidToken
+		payload, ok := jwtToken.Claims.(*Payload)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		return payload.checkType(tokenType)
 	}
-	return payload, nil
 }
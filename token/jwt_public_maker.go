@@ -0,0 +1,136 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const minRSAKeyBits = 2048
+
+var ErrRSAKeyTooShort = fmt.Errorf("RSA key should be at least %d bits", minRSAKeyBits)
+
+// JWTPublicMaker is a Json Web Token maker signing with an EdDSA or RS256 private key,
+// so the tokens can be verified without the secret
+type JWTPublicMaker struct {
+	keyID      string
+	method     jwt.SigningMethod
+	privateKey crypto.Signer
+	publicKeys *publicKeyring
+}
+
+// NewJWTPublicMaker returns a new JWTMaker signing with EdDSA for Ed25519 keys and RS256 for RSA keys,
+// the retired public keys verify the tokens signed before the key was rotated and must be of the same type
+func NewJWTPublicMaker(keyID string, privateKey crypto.Signer, retiredKeys map[string]crypto.PublicKey) (Maker, error) {
+	method, err := jwtSigningMethod(privateKey.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	publicKeys, err := newPublicKeyring(keyID, privateKey.Public(), retiredKeys, func(key crypto.PublicKey) error {
+		keyMethod, err := jwtSigningMethod(key)
+		if err != nil {
+			return err
+		}
+		if keyMethod != method {
+			return fmt.Errorf("%w: retired keys must use %s like the active key", ErrUnsupportedKey, method.Alg())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	maker := &JWTPublicMaker{
+		keyID:      keyID,
+		method:     method,
+		privateKey: privateKey,
+		publicKeys: publicKeys,
+	}
+	return maker, nil
+}
+
+// jwtSigningMethod returns the algorithm of a public key, RSA keys must be long enough
+func jwtSigningMethod(publicKey crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch key := publicKey.(type) {
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	case *rsa.PublicKey:
+		if key.N.BitLen() < minRSAKeyBits {
+			return nil, ErrRSAKeyTooShort
+		}
+		return jwt.SigningMethodRS256, nil
+	}
+	return nil, ErrUnsupportedKey
+}
+
+// CreateToken implements Maker.
+func (maker *JWTPublicMaker) CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, tokenType, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	jwtToken := jwt.NewWithClaims(maker.method, payload)
+	if maker.keyID != "" {
+		jwtToken.Header["kid"] = maker.keyID
+	}
+	token, err := jwtToken.SignedString(maker.privateKey)
+	return token, payload, err
+}
+
+// VerifyToken implements Maker.
+func (maker *JWTPublicMaker) VerifyToken(token string, tokenType TokenType) (*Payload, error) {
+	// the parser only reads the header here, the signature is verified below
+	unverifiedToken, _, err := new(jwt.Parser).ParseUnverified(token, &Payload{})
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	keyID, _ := unverifiedToken.Header["kid"].(string)
+
+	keys, err := maker.publicKeys.verificationKeys(keyID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	for _, key := range keys {
+		keyFunc := func(token *jwt.Token) (interface{}, error) {
+			// only the algorithm of the maker is accepted, otherwise a HMAC token signed
+			// with the public key would pass the verification
+			if token.Method.Alg() != maker.method.Alg() {
+				return nil, ErrInvalidToken
+			}
+			return key, nil
+		}
+
+		jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
+		if err != nil {
+			vErr, ok := err.(*jwt.ValidationError)
+			// try the next candidate key if the signature didn't match
+			if ok && vErr.Errors&jwt.ValidationErrorSignatureInvalid != 0 {
+				continue
+			}
+			if ok && errors.Is(vErr.Inner, ErrExpiredToken) {
+				return nil, ErrExpiredToken
+			}
+			return nil, ErrInvalidToken
+		}
+		payload, ok := jwtToken.Claims.(*Payload)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		return payload.checkType(tokenType)
+	}
+
+	return nil, ErrInvalidToken
+}
+
+// PublicKeys implements PublicKeyMaker.
+func (maker *JWTPublicMaker) PublicKeys() JSONWebKeySet {
+	return maker.publicKeys.jsonWebKeys(maker.method.Alg())
+}
@@ -0,0 +1,119 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+	"github.com/yuuban007/simplebank/util"
+)
+
+func TestJWTPublicMaker(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		privateKey crypto.Signer
+		algorithm  string
+	}{
+		{
+			name:       "EdDSA",
+			privateKey: randomEd25519Key(t),
+			algorithm:  "EdDSA",
+		},
+		{
+			name:       "RS256",
+			privateKey: rsaKey,
+			algorithm:  "RS256",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			maker, err := NewJWTPublicMaker("key1", tc.privateKey, nil)
+			require.NoError(t, err)
+
+			username := util.RandomOwner()
+			role := util.BankerRole
+			duration := time.Minute
+
+			issuedAt := time.Now()
+			expiredAt := issuedAt.Add(duration)
+
+			token, payload, err := maker.CreateToken(username, role, AccessToken, duration)
+			require.NoError(t, err)
+			require.NotEmpty(t, token)
+			require.NotEmpty(t, payload)
+
+			payload, err = maker.VerifyToken(token, AccessToken)
+			require.NoError(t, err)
+			require.NotEmpty(t, payload)
+
+			require.NotZero(t, payload.ID)
+			require.Equal(t, username, payload.Username)
+			require.Equal(t, role, payload.Role)
+			require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+			require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+
+			publicKeys := maker.(PublicKeyMaker).PublicKeys()
+			require.Len(t, publicKeys.Keys, 1)
+			require.Equal(t, tc.algorithm, publicKeys.Keys[0].Algorithm)
+			require.Equal(t, "key1", publicKeys.Keys[0].KeyID)
+
+			// expired token
+			token, _, err = maker.CreateToken(username, role, AccessToken, -time.Second)
+			require.NoError(t, err)
+			payload, err = maker.VerifyToken(token, AccessToken)
+			require.ErrorIs(t, err, ErrExpiredToken)
+			require.Nil(t, payload)
+		})
+	}
+}
+
+func TestJWTPublicMakerWeakRSAKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	maker, err := NewJWTPublicMaker("", rsaKey, nil)
+	require.Error(t, err)
+	require.Nil(t, maker)
+}
+
+func TestInvalidJWTPublicTokenAlgHMAC(t *testing.T) {
+	privateKey := randomEd25519Key(t)
+	maker, err := NewJWTPublicMaker("", privateKey, nil)
+	require.NoError(t, err)
+
+	// a token signed with HMAC using the public key as secret must be rejected
+	payload, err := NewPayload(util.RandomOwner(), util.AdminRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
+	token, err := jwtToken.SignedString([]byte(privateKey.Public().(ed25519.PublicKey)))
+	require.NoError(t, err)
+
+	payload, err = maker.VerifyToken(token, AccessToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Nil(t, payload)
+}
+
+func TestInvalidJWTPublicTokenKeyID(t *testing.T) {
+	privateKey := randomEd25519Key(t)
+	otherMaker, err := NewJWTPublicMaker("other", privateKey, nil)
+	require.NoError(t, err)
+	token, _, err := otherMaker.CreateToken(util.RandomOwner(), util.DepositorRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	maker, err := NewJWTPublicMaker("key1", privateKey, nil)
+	require.NoError(t, err)
+	payload, err := maker.VerifyToken(token, AccessToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Nil(t, payload)
+}
@@ -0,0 +1,78 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrUnknownKeyID = errors.New("unknown token key id")
+
+// Keyring holds the symmetric keys of a token maker.
+// New tokens are created with the active key, and the retired keys are only used to verify
+// tokens that were created before the active key was rotated.
+type Keyring struct {
+	activeKeyID string
+	keys        map[string][]byte
+}
+
+// NewKeyring returns a new Keyring, retiredKeys maps key ids to the keys that are still accepted
+func NewKeyring(activeKeyID string, activeKey string, retiredKeys map[string]string) (*Keyring, error) {
+	if len(retiredKeys) > 0 && activeKeyID == "" {
+		return nil, errors.New("the active key needs a key id when retired keys are configured")
+	}
+
+	keyring := &Keyring{
+		activeKeyID: activeKeyID,
+		keys:        map[string][]byte{activeKeyID: []byte(activeKey)},
+	}
+	for keyID, key := range retiredKeys {
+		if keyID == "" {
+			return nil, errors.New("retired keys must have a key id")
+		}
+		if keyID == activeKeyID {
+			return nil, fmt.Errorf("key id %s is used by both the active key and a retired key", keyID)
+		}
+		keyring.keys[keyID] = []byte(key)
+	}
+	return keyring, nil
+}
+
+// validate checks every key of the keyring with the given function
+func (keyring *Keyring) validate(check func(key []byte) error) error {
+	for keyID, key := range keyring.keys {
+		if err := check(key); err != nil {
+			if keyID == "" {
+				return err
+			}
+			return fmt.Errorf("key %s: %w", keyID, err)
+		}
+	}
+	return nil
+}
+
+// activeKey returns the key used to create new tokens and its key id
+func (keyring *Keyring) activeKey() (string, []byte) {
+	return keyring.activeKeyID, keyring.keys[keyring.activeKeyID]
+}
+
+// verificationKeys returns the keys a token with the given key id may have been created with.
+// Tokens without a key id were created before key ids were configured,
+// so they are checked against every key, starting with the active one.
+func (keyring *Keyring) verificationKeys(keyID string) ([][]byte, error) {
+	if keyID != "" {
+		key, ok := keyring.keys[keyID]
+		if !ok {
+			return nil, ErrUnknownKeyID
+		}
+		return [][]byte{key}, nil
+	}
+
+	_, activeKey := keyring.activeKey()
+	keys := [][]byte{activeKey}
+	for id, key := range keyring.keys {
+		if id != keyring.activeKeyID {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,125 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuuban007/simplebank/util"
+)
+
+func TestNewKeyring(t *testing.T) {
+	// retired keys need the active key to be identified
+	keyring, err := NewKeyring("", util.RandomString(32), map[string]string{"old": util.RandomString(32)})
+	require.Error(t, err)
+	require.Nil(t, keyring)
+
+	keyring, err = NewKeyring("key", util.RandomString(32), map[string]string{"key": util.RandomString(32)})
+	require.Error(t, err)
+	require.Nil(t, keyring)
+
+	keyring, err = NewKeyring("key", util.RandomString(32), map[string]string{"": util.RandomString(32)})
+	require.Error(t, err)
+	require.Nil(t, keyring)
+
+	keyring, err = NewKeyring("new", util.RandomString(32), map[string]string{"old": util.RandomString(32)})
+	require.NoError(t, err)
+	require.NotNil(t, keyring)
+}
+
+func testKeyRotation(t *testing.T, newMaker func(keyring *Keyring) (Maker, error)) {
+	oldKey := util.RandomString(32)
+	newKey := util.RandomString(32)
+	username := util.RandomOwner()
+
+	oldKeyring, err := NewKeyring("old", oldKey, nil)
+	require.NoError(t, err)
+	oldMaker, err := newMaker(oldKeyring)
+	require.NoError(t, err)
+
+	oldToken, _, err := oldMaker.CreateToken(username, util.DepositorRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	// the old key is rotated out but still accepted
+	rotatedKeyring, err := NewKeyring("new", newKey, map[string]string{"old": oldKey})
+	require.NoError(t, err)
+	rotatedMaker, err := newMaker(rotatedKeyring)
+	require.NoError(t, err)
+
+	payload, err := rotatedMaker.VerifyToken(oldToken, AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, username, payload.Username)
+
+	newToken, _, err := rotatedMaker.CreateToken(username, util.DepositorRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+	payload, err = rotatedMaker.VerifyToken(newToken, AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, username, payload.Username)
+
+	// tokens of the new key are unknown to the old keyring
+	payload, err = oldMaker.VerifyToken(newToken, AccessToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Nil(t, payload)
+
+	// once the old key is dropped its tokens are rejected
+	newKeyring, err := NewKeyring("new", newKey, nil)
+	require.NoError(t, err)
+	newMaker2, err := newMaker(newKeyring)
+	require.NoError(t, err)
+
+	payload, err = newMaker2.VerifyToken(oldToken, AccessToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Nil(t, payload)
+
+	// expired tokens of a retired key are still reported as expired
+	expiredToken, _, err := oldMaker.CreateToken(username, util.DepositorRole, AccessToken, -time.Second)
+	require.NoError(t, err)
+	payload, err = rotatedMaker.VerifyToken(expiredToken, AccessToken)
+	require.ErrorIs(t, err, ErrExpiredToken)
+	require.Nil(t, payload)
+}
+
+func testLegacyTokenWithoutKeyID(t *testing.T, newMaker func(keyring *Keyring) (Maker, error)) {
+	oldKey := util.RandomString(32)
+	username := util.RandomOwner()
+
+	legacyKeyring, err := NewKeyring("", oldKey, nil)
+	require.NoError(t, err)
+	legacyMaker, err := newMaker(legacyKeyring)
+	require.NoError(t, err)
+
+	legacyToken, _, err := legacyMaker.CreateToken(username, util.DepositorRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	keyring, err := NewKeyring("new", util.RandomString(32), map[string]string{"old": oldKey})
+	require.NoError(t, err)
+	maker, err := newMaker(keyring)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(legacyToken, AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, username, payload.Username)
+}
+
+func TestPasetoKeyRotation(t *testing.T) {
+	testKeyRotation(t, NewPasetoKeyringMaker)
+	testLegacyTokenWithoutKeyID(t, NewPasetoKeyringMaker)
+
+	// every key must have the right size
+	keyring, err := NewKeyring("new", util.RandomString(32), map[string]string{"old": util.RandomString(11)})
+	require.NoError(t, err)
+	maker, err := NewPasetoKeyringMaker(keyring)
+	require.Error(t, err)
+	require.Nil(t, maker)
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	testKeyRotation(t, NewJWTKeyringMaker)
+	testLegacyTokenWithoutKeyID(t, NewJWTKeyringMaker)
+
+	keyring, err := NewKeyring("new", util.RandomString(32), map[string]string{"old": util.RandomString(11)})
+	require.NoError(t, err)
+	maker, err := NewJWTKeyringMaker(keyring)
+	require.ErrorIs(t, err, ErrSecretKeyTooShort)
+	require.Nil(t, maker)
+}
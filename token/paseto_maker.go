@@ -10,43 +10,82 @@ import (
 
 // PasetoMaker is a PASETO token maker
 type PasetoMaker struct {
-	paseto       *paseto.V2
-	symmetricKey []byte
+	paseto  *paseto.V2
+	keyring *Keyring
+}
+
+// pasetoFooter is the unencrypted footer of the token, it tells which key the token was created with
+type pasetoFooter struct {
+	KeyID string `json:"kid"`
 }
 
 func NewPasetoMaker(symmetricKey string) (Maker, error) {
-	if len(symmetricKey) != chacha20poly1305.KeySize {
-		return nil, fmt.Errorf("invalid key size: must be exactly %d characters", chacha20poly1305.KeySize)
+	keyring, err := NewKeyring("", symmetricKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	return NewPasetoKeyringMaker(keyring)
+}
+
+// NewPasetoKeyringMaker returns a PasetoMaker that supports key rotation
+func NewPasetoKeyringMaker(keyring *Keyring) (Maker, error) {
+	err := keyring.validate(func(key []byte) error {
+		if len(key) != chacha20poly1305.KeySize {
+			return fmt.Errorf("invalid key size: must be exactly %d characters", chacha20poly1305.KeySize)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	maker := &PasetoMaker{
-		paseto:       paseto.NewV2(),
-		symmetricKey: []byte(symmetricKey),
+		paseto:  paseto.NewV2(),
+		keyring: keyring,
 	}
 	return maker, nil
 }
 
 // CreateToken implements Maker.
-func (maker *PasetoMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload, err := NewPayload(username, duration)
+func (maker *PasetoMaker) CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, tokenType, duration)
 	if err != nil {
-		return "", err
+		return "", payload, err
+	}
+
+	keyID, key := maker.keyring.activeKey()
+	var footer interface{}
+	if keyID != "" {
+		footer = pasetoFooter{KeyID: keyID}
 	}
-	return maker.paseto.Encrypt(maker.symmetricKey, payload, nil)
+	token, err := maker.paseto.Encrypt(key, payload, footer)
+	return token, payload, err
 }
 
 // VerifyToken implements Maker.
-func (maker *PasetoMaker) VerifyToken(token string) (*Payload, error) {
-	payload := &Payload{}
+func (maker *PasetoMaker) VerifyToken(token string, tokenType TokenType) (*Payload, error) {
+	var footer pasetoFooter
+	if err := paseto.ParseFooter(token, &footer); err != nil {
+		return nil, ErrInvalidToken
+	}
 
-	err := maker.paseto.Decrypt(token, maker.symmetricKey, payload, nil)
+	keys, err := maker.keyring.verificationKeys(footer.KeyID)
 	if err != nil {
 		return nil, ErrInvalidToken
 	}
-	err = payload.Valid()
-	if err != nil {
-		return nil, err
+
+	for _, key := range keys {
+		payload := &Payload{}
+		err := maker.paseto.Decrypt(token, key, payload, nil)
+		if err != nil {
+			continue
+		}
+		err = payload.Valid()
+		if err != nil {
+			return nil, err
+		}
+		return payload.checkType(tokenType)
 	}
 
-	return payload, nil
+	return nil, ErrInvalidToken
 }
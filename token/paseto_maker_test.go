@@ -20,21 +20,24 @@ func TestPasetoMaker(t *testing.T) {
 	require.NoError(t, err)
 
 	username := util.RandomOwner()
+	role := util.DepositorRole
 	duration := time.Minute
 
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
-	token, err := maker.CreateToken(username, duration)
+	token, payload, err := maker.CreateToken(username, role, AccessToken, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
 
-	payload, err := maker.VerifyToken(token)
+	payload, err = maker.VerifyToken(token, AccessToken)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 
 	require.NotZero(t, payload.ID)
 	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
 }
@@ -46,11 +49,12 @@ func TestExpiredPasetoToken(t *testing.T) {
 
 	username := util.RandomOwner()
 
-	token, err := maker.CreateToken(username, -time.Second)
+	token, payload, err := maker.CreateToken(username, util.DepositorRole, AccessToken, -time.Second)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
 
-	payload, err := maker.VerifyToken(token)
+	payload, err = maker.VerifyToken(token, AccessToken)
 	require.Error(t, err, ErrExpiredToken.Error())
 	require.Nil(t, payload)
 }
@@ -58,7 +62,7 @@ func TestExpiredPasetoToken(t *testing.T) {
 func TestInvalidPasetoToken(t *testing.T) {
 
 	// create a token with different symmetricKey
-	payload, err := NewPayload(util.RandomOwner(), time.Minute)
+	payload, err := NewPayload(util.RandomOwner(), util.DepositorRole, AccessToken, time.Minute)
 	require.NoError(t, err)
 	wrongKey := util.RandomString(32)
 	token, err := paseto.NewV2().Encrypt([]byte(wrongKey), payload, nil)
@@ -67,7 +71,24 @@ func TestInvalidPasetoToken(t *testing.T) {
 	maker, err := NewPasetoMaker(util.RandomString(32))
 	require.NoError(t, err)
 
-	payload, err = maker.VerifyToken(token)
+	payload, err = maker.VerifyToken(token, AccessToken)
 	require.Error(t, err, ErrInvalidToken)
 	require.Nil(t, payload)
 }
+
+func TestPasetoTokenWrongType(t *testing.T) {
+	maker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	token, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, RefreshToken, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, RefreshToken, payload.Type)
+
+	payload, err = maker.VerifyToken(token, AccessToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Nil(t, payload)
+
+	payload, err = maker.VerifyToken(token, RefreshToken)
+	require.NoError(t, err)
+	require.Equal(t, RefreshToken, payload.Type)
+}
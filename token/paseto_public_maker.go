@@ -0,0 +1,130 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+)
+
+// PasetoPublicMaker is a PASETO v4.public token maker,
+// tokens are signed with an Ed25519 private key so they can be verified without the secret
+type PasetoPublicMaker struct {
+	keyID      string
+	secretKey  paseto.V4AsymmetricSecretKey
+	publicKeys *publicKeyring
+}
+
+var errPasetoPublicKeyType = errors.New("invalid key type: PASETO v4.public requires an Ed25519 key")
+
+// NewPasetoPublicMaker returns a new PasetoPublicMaker signing with an Ed25519 private key,
+// the retired public keys verify the tokens signed before the key was rotated
+func NewPasetoPublicMaker(keyID string, privateKey crypto.Signer, retiredKeys map[string]crypto.PublicKey) (Maker, error) {
+	ed25519Key, ok := privateKey.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errPasetoPublicKeyType
+	}
+
+	secretKey, err := paseto.NewV4AsymmetricSecretKeyFromEd25519(ed25519Key)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKeys, err := newPublicKeyring(keyID, ed25519Key.Public(), retiredKeys, func(key crypto.PublicKey) error {
+		if _, ok := key.(ed25519.PublicKey); !ok {
+			return errPasetoPublicKeyType
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	maker := &PasetoPublicMaker{
+		keyID:      keyID,
+		secretKey:  secretKey,
+		publicKeys: publicKeys,
+	}
+	return maker, nil
+}
+
+// CreateToken implements Maker.
+func (maker *PasetoPublicMaker) CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, tokenType, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	claims, err := json.Marshal(payload)
+	if err != nil {
+		return "", payload, err
+	}
+
+	var footer []byte
+	if maker.keyID != "" {
+		footer, err = json.Marshal(pasetoFooter{KeyID: maker.keyID})
+		if err != nil {
+			return "", payload, err
+		}
+	}
+
+	token, err := paseto.NewTokenFromClaimsJSON(claims, footer)
+	if err != nil {
+		return "", payload, err
+	}
+	return token.V4Sign(maker.secretKey, nil), payload, nil
+}
+
+// VerifyToken implements Maker.
+func (maker *PasetoPublicMaker) VerifyToken(token string, tokenType TokenType) (*Payload, error) {
+	// the payload checks the expiration itself
+	parser := paseto.NewParserWithoutExpiryCheck()
+
+	// the footer tells which key signed the token, it is only trusted once the signature is verified
+	var footer pasetoFooter
+	rawFooter, err := parser.UnsafeParseFooter(paseto.V4Public, token)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if len(rawFooter) > 0 {
+		if err := json.Unmarshal(rawFooter, &footer); err != nil {
+			return nil, ErrInvalidToken
+		}
+	}
+
+	keys, err := maker.publicKeys.verificationKeys(footer.KeyID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	for _, key := range keys {
+		publicKey, err := paseto.NewV4AsymmetricPublicKeyFromEd25519(key.(ed25519.PublicKey))
+		if err != nil {
+			return nil, ErrInvalidToken
+		}
+		parsedToken, err := parser.ParseV4Public(publicKey, token, nil)
+		if err != nil {
+			continue
+		}
+
+		payload := &Payload{}
+		if err := json.Unmarshal(parsedToken.ClaimsJSON(), payload); err != nil {
+			return nil, ErrInvalidToken
+		}
+		err = payload.Valid()
+		if err != nil {
+			return nil, err
+		}
+		return payload.checkType(tokenType)
+	}
+
+	return nil, ErrInvalidToken
+}
+
+// PublicKeys implements PublicKeyMaker.
+func (maker *PasetoPublicMaker) PublicKeys() JSONWebKeySet {
+	return maker.publicKeys.jsonWebKeys("")
+}
@@ -0,0 +1,129 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuuban007/simplebank/util"
+)
+
+func randomEd25519Key(t *testing.T) ed25519.PrivateKey {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return privateKey
+}
+
+func TestPasetoPublicMaker(t *testing.T) {
+	// test unsupported key
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	maker, err := NewPasetoPublicMaker("key1", rsaKey, nil)
+	require.Error(t, err)
+	require.Empty(t, maker)
+
+	// test happy case
+	maker, err = NewPasetoPublicMaker("key1", randomEd25519Key(t), nil)
+	require.NoError(t, err)
+
+	username := util.RandomOwner()
+	role := util.DepositorRole
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	token, payload, err := maker.CreateToken(username, role, AccessToken, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token, AccessToken)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	require.NotZero(t, payload.ID)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+
+	publicKeys := maker.(PublicKeyMaker).PublicKeys()
+	require.Len(t, publicKeys.Keys, 1)
+	require.Equal(t, "OKP", publicKeys.Keys[0].KeyType)
+	require.Equal(t, "Ed25519", publicKeys.Keys[0].Curve)
+	require.Equal(t, "key1", publicKeys.Keys[0].KeyID)
+	require.NotEmpty(t, publicKeys.Keys[0].X)
+}
+
+func TestExpiredPasetoPublicToken(t *testing.T) {
+	maker, err := NewPasetoPublicMaker("", randomEd25519Key(t), nil)
+	require.NoError(t, err)
+
+	token, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, AccessToken, -time.Second)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token, AccessToken)
+	require.ErrorIs(t, err, ErrExpiredToken)
+	require.Nil(t, payload)
+}
+
+func TestInvalidPasetoPublicToken(t *testing.T) {
+	// create a token with a different private key
+	otherMaker, err := NewPasetoPublicMaker("", randomEd25519Key(t), nil)
+	require.NoError(t, err)
+	token, _, err := otherMaker.CreateToken(util.RandomOwner(), util.DepositorRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	maker, err := NewPasetoPublicMaker("", randomEd25519Key(t), nil)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token, AccessToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Nil(t, payload)
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	ed25519Key := randomEd25519Key(t)
+	der, err := x509.MarshalPKCS8PrivateKey(ed25519Key)
+	require.NoError(t, err)
+
+	key, err := ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	require.NoError(t, err)
+	require.Equal(t, ed25519Key, key)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	key, err = ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}))
+	require.NoError(t, err)
+	require.True(t, rsaKey.Equal(key))
+
+	_, err = ParsePrivateKey([]byte("not a pem"))
+	require.Error(t, err)
+}
+
+func TestParsePublicKey(t *testing.T) {
+	ed25519Key := randomEd25519Key(t)
+	der, err := x509.MarshalPKIXPublicKey(ed25519Key.Public())
+	require.NoError(t, err)
+
+	key, err := ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	require.NoError(t, err)
+	require.Equal(t, ed25519Key.Public(), key)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	key, err = ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)}))
+	require.NoError(t, err)
+	require.True(t, rsaKey.PublicKey.Equal(key))
+
+	_, err = ParsePublicKey([]byte("not a pem"))
+	require.Error(t, err)
+}
@@ -7,10 +7,21 @@ import (
 	"github.com/google/uuid"
 )
 
+// TokenType tells what a token is for, so that a refresh token can't be used as an access token
+type TokenType string
+
+// Constants for all token types
+const (
+	AccessToken  TokenType = "access"
+	RefreshToken TokenType = "refresh"
+)
+
 // Payload contains the payload data of the token
 type Payload struct {
 	ID        uuid.UUID `json:"id"`
+	Type      TokenType `json:"token_type"`
 	Username  string    `json:"username"`
+	Role      string    `json:"role"`
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
 }
@@ -29,16 +40,26 @@ func (payload *Payload) Valid() error {
 }
 
 // NewPayload returns a new Payload
-func NewPayload(username string, duration time.Duration) (*Payload, error) {
+func NewPayload(username string, role string, tokenType TokenType, duration time.Duration) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
 	}
 	payload := &Payload{
 		ID:        tokenID,
+		Type:      tokenType,
 		Username:  username,
+		Role:      role,
 		IssuedAt:  time.Now(),
 		ExpiredAt: time.Now().Add(duration),
 	}
 	return payload, nil
 }
+
+// checkType rejects a valid token that was created for another purpose
+func (payload *Payload) checkType(tokenType TokenType) (*Payload, error) {
+	if payload.Type != tokenType {
+		return nil, ErrInvalidToken
+	}
+	return payload, nil
+}
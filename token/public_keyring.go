@@ -0,0 +1,80 @@
+package token
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// publicKeyring holds the public keys of an asymmetric token maker, like Keyring does for the symmetric ones.
+// The active key verifies the tokens signed with the private key of the maker, the retired keys verify
+// the tokens that were signed before the private key was rotated.
+type publicKeyring struct {
+	activeKeyID string
+	keys        map[string]crypto.PublicKey
+}
+
+// newPublicKeyring returns a new publicKeyring, check must accept the type of every key
+func newPublicKeyring(activeKeyID string, activeKey crypto.PublicKey, retiredKeys map[string]crypto.PublicKey, check func(key crypto.PublicKey) error) (*publicKeyring, error) {
+	if len(retiredKeys) > 0 && activeKeyID == "" {
+		return nil, errors.New("the active key needs a key id when retired keys are configured")
+	}
+
+	keyring := &publicKeyring{
+		activeKeyID: activeKeyID,
+		keys:        map[string]crypto.PublicKey{activeKeyID: activeKey},
+	}
+	for keyID, key := range retiredKeys {
+		if keyID == "" {
+			return nil, errors.New("retired keys must have a key id")
+		}
+		if keyID == activeKeyID {
+			return nil, fmt.Errorf("key id %s is used by both the active key and a retired key", keyID)
+		}
+		if err := check(key); err != nil {
+			return nil, fmt.Errorf("key %s: %w", keyID, err)
+		}
+		keyring.keys[keyID] = key
+	}
+	return keyring, nil
+}
+
+// verificationKeys returns the keys a token with the given key id may have been signed with.
+// Tokens without a key id were signed before key ids were configured,
+// so they are checked against every key, starting with the active one.
+func (keyring *publicKeyring) verificationKeys(keyID string) ([]crypto.PublicKey, error) {
+	if keyID != "" {
+		key, ok := keyring.keys[keyID]
+		if !ok {
+			return nil, ErrUnknownKeyID
+		}
+		return []crypto.PublicKey{key}, nil
+	}
+
+	keys := []crypto.PublicKey{keyring.keys[keyring.activeKeyID]}
+	for id, key := range keyring.keys {
+		if id != keyring.activeKeyID {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// jsonWebKeys publishes every key, the retired ones still verify tokens in circulation
+func (keyring *publicKeyring) jsonWebKeys(algorithm string) JSONWebKeySet {
+	keySet := JSONWebKeySet{
+		Keys: []JSONWebKey{newJSONWebKey(keyring.activeKeyID, algorithm, keyring.keys[keyring.activeKeyID])},
+	}
+	retiredKeyIDs := make([]string, 0, len(keyring.keys)-1)
+	for id := range keyring.keys {
+		if id != keyring.activeKeyID {
+			retiredKeyIDs = append(retiredKeyIDs, id)
+		}
+	}
+	sort.Strings(retiredKeyIDs)
+	for _, id := range retiredKeyIDs {
+		keySet.Keys = append(keySet.Keys, newJSONWebKey(id, algorithm, keyring.keys[id]))
+	}
+	return keySet
+}
@@ -0,0 +1,99 @@
+package token
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuuban007/simplebank/util"
+)
+
+// newPublicMakerFunc creates an asymmetric token maker
+type newPublicMakerFunc func(keyID string, privateKey crypto.Signer, retiredKeys map[string]crypto.PublicKey) (Maker, error)
+
+// testPublicKeyRotation checks that the tokens signed with a rotated private key still verify
+// once its public key is retired, and that the JWKS publishes it
+func testPublicKeyRotation(t *testing.T, newMaker newPublicMakerFunc, newKey func() crypto.Signer) {
+	oldKey := newKey()
+	oldMaker, err := newMaker("old", oldKey, nil)
+	require.NoError(t, err)
+	oldToken, _, err := oldMaker.CreateToken(util.RandomOwner(), util.DepositorRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+
+	newMaker1, err := newMaker("new", newKey(), map[string]crypto.PublicKey{"old": oldKey.Public()})
+	require.NoError(t, err)
+
+	_, err = newMaker1.VerifyToken(oldToken, AccessToken)
+	require.NoError(t, err)
+
+	newToken, _, err := newMaker1.CreateToken(util.RandomOwner(), util.DepositorRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+	_, err = newMaker1.VerifyToken(newToken, AccessToken)
+	require.NoError(t, err)
+
+	// the old maker doesn't know the new key
+	_, err = oldMaker.VerifyToken(newToken, AccessToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	keySet := newMaker1.(PublicKeyMaker).PublicKeys()
+	require.Len(t, keySet.Keys, 2)
+	require.Equal(t, "new", keySet.Keys[0].KeyID)
+	require.Equal(t, "old", keySet.Keys[1].KeyID)
+
+	// once the retired key is dropped its tokens are rejected
+	newMaker2, err := newMaker("new", newKey(), nil)
+	require.NoError(t, err)
+	_, err = newMaker2.VerifyToken(oldToken, AccessToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	// an expired token signed with a retired key is reported as expired
+	expiredToken, _, err := oldMaker.CreateToken(util.RandomOwner(), util.DepositorRole, AccessToken, -time.Second)
+	require.NoError(t, err)
+	_, err = newMaker1.VerifyToken(expiredToken, AccessToken)
+	require.ErrorIs(t, err, ErrExpiredToken)
+
+	// tokens without a key id are checked against every key
+	legacyMaker, err := newMaker("", oldKey, nil)
+	require.NoError(t, err)
+	legacyToken, _, err := legacyMaker.CreateToken(util.RandomOwner(), util.DepositorRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+	_, err = newMaker1.VerifyToken(legacyToken, AccessToken)
+	require.NoError(t, err)
+
+	// retired keys need the active key to have a key id
+	_, err = newMaker("", newKey(), map[string]crypto.PublicKey{"old": oldKey.Public()})
+	require.Error(t, err)
+}
+
+func TestPasetoPublicKeyRotation(t *testing.T) {
+	testPublicKeyRotation(t, NewPasetoPublicMaker, func() crypto.Signer { return randomEd25519Key(t) })
+
+	// retired keys must be Ed25519 keys too
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, err = NewPasetoPublicMaker("new", randomEd25519Key(t), map[string]crypto.PublicKey{"old": rsaKey.Public()})
+	require.Error(t, err)
+}
+
+func TestJWTPublicKeyRotation(t *testing.T) {
+	testPublicKeyRotation(t, NewJWTPublicMaker, func() crypto.Signer { return randomEd25519Key(t) })
+	testPublicKeyRotation(t, NewJWTPublicMaker, func() crypto.Signer {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		return rsaKey
+	})
+
+	// retired keys must use the algorithm of the active key
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, err = NewJWTPublicMaker("new", randomEd25519Key(t), map[string]crypto.PublicKey{"old": rsaKey.Public()})
+	require.ErrorIs(t, err, ErrUnsupportedKey)
+
+	shortKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	_, err = NewJWTPublicMaker("new", rsaKey, map[string]crypto.PublicKey{"old": shortKey.Public()})
+	require.ErrorIs(t, err, ErrRSAKeyTooShort)
+}
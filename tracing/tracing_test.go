@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yuuban007/simplebank/util"
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetup(t *testing.T) {
+	testCases := []struct {
+		name     string
+		exporter string
+		checkErr func(t *testing.T, err error)
+	}{
+		{
+			name:     "Default",
+			exporter: "",
+			checkErr: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:     "None",
+			exporter: NoneExporter,
+			checkErr: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:     "Stdout",
+			exporter: StdoutExporter,
+			checkErr: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:     "Unsupported",
+			exporter: "zipkin",
+			checkErr: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrUnsupportedExporter)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			shutdown, err := Setup(context.Background(), util.Config{TracingExporter: tc.exporter})
+			tc.checkErr(t, err)
+			if err != nil {
+				return
+			}
+			require.NoError(t, shutdown(context.Background()))
+
+			// the trace context is propagated even when tracing is off
+			require.ElementsMatch(t, []string{"traceparent", "tracestate", "baggage"}, otel.GetTextMapPropagator().Fields())
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package util
+
+// Constants for all account statuses
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen"
+	AccountStatusClosed = "closed"
+)
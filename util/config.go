@@ -1,6 +1,8 @@
 package util
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -9,11 +11,38 @@ import (
 // config stores all configuration of the application
 // the value are read by viper from a config file or environment variables
 type Config struct {
-	DBSource            string        `mapstructure:"DB_SOURCE"`
-	DBDriver            string        `mapstructure:"DB_DRIVER"`
-	ServerAddress       string        `mapstructure:"SERVER_ADDRESS"`
-	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	DBSource              string        `mapstructure:"DB_SOURCE"`
+	DBConnectTimeout      time.Duration `mapstructure:"DB_CONNECT_TIMEOUT"`
+	ServerAddress         string        `mapstructure:"SERVER_ADDRESS"`
+	GRPCServerAddress     string        `mapstructure:"GRPC_SERVER_ADDRESS"`
+	MetricsAddress        string        `mapstructure:"METRICS_ADDRESS"`
+	HTTPReadTimeout       time.Duration `mapstructure:"HTTP_READ_TIMEOUT"`
+	HTTPWriteTimeout      time.Duration `mapstructure:"HTTP_WRITE_TIMEOUT"`
+	HTTPExportTimeout     time.Duration `mapstructure:"HTTP_EXPORT_TIMEOUT"`
+	HTTPIdleTimeout       time.Duration `mapstructure:"HTTP_IDLE_TIMEOUT"`
+	ShutdownTimeout       time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	TokenType             string        `mapstructure:"TOKEN_TYPE"`
+	TokenSymmetricKey     string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	TokenKeyID            string        `mapstructure:"TOKEN_KEY_ID"`
+	TokenRetiredKeys      string        `mapstructure:"TOKEN_RETIRED_KEYS"`
+	TokenPrivateKeyPath   string        `mapstructure:"TOKEN_PRIVATE_KEY_PATH"`
+	TokenRetiredKeyPaths  string        `mapstructure:"TOKEN_RETIRED_KEY_PATHS"`
+	AccessTokenDuration   time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration  time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	IdempotencyKeyTTL     time.Duration `mapstructure:"IDEMPOTENCY_KEY_TTL"`
+	FXRatesFile           string        `mapstructure:"FX_RATES_FILE"`
+	CursorSecretKey       string        `mapstructure:"CURSOR_SECRET_KEY"`
+	TracingExporter       string        `mapstructure:"TRACING_EXPORTER"`
+	OTLPEndpoint          string        `mapstructure:"OTLP_ENDPOINT"`
+	LogFormat             string        `mapstructure:"LOG_FORMAT"`
+	LogLevel              string        `mapstructure:"LOG_LEVEL"`
+	RateLimitBackend      string        `mapstructure:"RATE_LIMIT_BACKEND"`
+	RateLimitWindow       time.Duration `mapstructure:"RATE_LIMIT_WINDOW"`
+	RateLimitIPRequests   int64         `mapstructure:"RATE_LIMIT_IP_REQUESTS"`
+	RateLimitUserRequests int64         `mapstructure:"RATE_LIMIT_USER_REQUESTS"`
+	LoginMaxFailures      int64         `mapstructure:"LOGIN_MAX_FAILURES"`
+	LoginLockoutDuration  time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	TrustedProxies        string        `mapstructure:"TRUSTED_PROXIES"`
 }
 
 // loadConfig reads configuration from file path or environment
@@ -29,3 +58,48 @@ func LoadConfig(path string) (config Config, err error) {
 	err = viper.Unmarshal(&config)
 	return
 }
+
+// TrustedProxyList parses TRUSTED_PROXIES, a comma separated list of the IPs or CIDRs of the proxies
+// whose X-Forwarded-For header tells the client IP, the header of any other peer is ignored
+func (config Config) TrustedProxyList() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(config.TrustedProxies, ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// RetiredTokenKeys parses TOKEN_RETIRED_KEYS, a comma separated list of key_id:key pairs
+// of the keys that were rotated out but must still verify the tokens they created
+func (config Config) RetiredTokenKeys() (map[string]string, error) {
+	return parseKeyIDPairs(config.TokenRetiredKeys, "key")
+}
+
+// RetiredTokenKeyPaths parses TOKEN_RETIRED_KEY_PATHS, a comma separated list of key_id:path pairs
+// of the PEM public keys whose private keys were rotated out but whose tokens must still verify
+func (config Config) RetiredTokenKeyPaths() (map[string]string, error) {
+	return parseKeyIDPairs(config.TokenRetiredKeyPaths, "path")
+}
+
+// parseKeyIDPairs parses a comma separated list of key_id:value pairs
+func parseKeyIDPairs(list string, valueName string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, pair := range strings.Split(list, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		keyID, value, found := strings.Cut(pair, ":")
+		if !found || keyID == "" || value == "" {
+			return nil, fmt.Errorf("invalid retired token key %q, expected key_id:%s", pair, valueName)
+		}
+		if _, ok := values[keyID]; ok {
+			return nil, fmt.Errorf("duplicated retired token key id %s", keyID)
+		}
+		values[keyID] = value
+	}
+	return values, nil
+}
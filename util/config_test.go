@@ -0,0 +1,52 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetiredTokenKeys(t *testing.T) {
+	config := Config{TokenRetiredKeys: ""}
+	keys, err := config.RetiredTokenKeys()
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	config.TokenRetiredKeys = "key1:12345678901234567890123456789012, key2:abcdefghijklmnopqrstuvwxyzabcdef"
+	keys, err = config.RetiredTokenKeys()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"key1": "12345678901234567890123456789012",
+		"key2": "abcdefghijklmnopqrstuvwxyzabcdef",
+	}, keys)
+
+	config.TokenRetiredKeys = "key1"
+	_, err = config.RetiredTokenKeys()
+	require.Error(t, err)
+
+	config.TokenRetiredKeys = "key1:a,key1:b"
+	_, err = config.RetiredTokenKeys()
+	require.Error(t, err)
+}
+
+func TestRetiredTokenKeyPaths(t *testing.T) {
+	config := Config{TokenRetiredKeyPaths: "key1:/keys/key1.pub.pem, key2:/keys/key2.pub.pem"}
+	paths, err := config.RetiredTokenKeyPaths()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"key1": "/keys/key1.pub.pem",
+		"key2": "/keys/key2.pub.pem",
+	}, paths)
+
+	config.TokenRetiredKeyPaths = "/keys/key1.pub.pem"
+	_, err = config.RetiredTokenKeyPaths()
+	require.Error(t, err)
+}
+
+func TestTrustedProxyList(t *testing.T) {
+	config := Config{TrustedProxies: ""}
+	require.Empty(t, config.TrustedProxyList())
+
+	config.TrustedProxies = "10.0.0.0/8, 192.168.1.1,"
+	require.Equal(t, []string{"10.0.0.0/8", "192.168.1.1"}, config.TrustedProxyList())
+}
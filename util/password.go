@@ -2,6 +2,7 @@ package util
 
 import (
 	"fmt"
+	"sync"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -19,3 +20,18 @@ func HashPassword(password string) (string, error) {
 func CheckPassword(password, hashedPassword string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+// dummyHashedPassword is a hash of no user's password, created once on first use
+var dummyHashedPassword = sync.OnceValue(func() string {
+	hashedPassword, err := HashPassword(RandomString(16))
+	if err != nil {
+		panic(err)
+	}
+	return hashedPassword
+})
+
+// CheckDummyPassword spends as long as CheckPassword checking a password against no user's hash,
+// so a login of a missing user takes as long as a wrong password and doesn't tell the user doesn't exist
+func CheckDummyPassword(password string) {
+	CheckPassword(password, dummyHashedPassword())
+}
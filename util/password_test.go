@@ -2,6 +2,7 @@ package util
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -24,3 +25,20 @@ func TestPassword(t *testing.T) {
 	err = CheckPassword(password, hashedPassword2)
 	require.NoError(t, err)
 }
+
+func TestCheckDummyPassword(t *testing.T) {
+	hashedPassword, err := HashPassword(RandomString(8))
+	require.NoError(t, err)
+	CheckDummyPassword(RandomString(8))
+
+	start := time.Now()
+	CheckPassword(RandomString(8), hashedPassword)
+	checkDuration := time.Since(start)
+
+	start = time.Now()
+	CheckDummyPassword(RandomString(8))
+	dummyDuration := time.Since(start)
+
+	// both run a full bcrypt comparison, one isn't orders of magnitude faster
+	require.Greater(t, dummyDuration, checkDuration/10)
+}
@@ -0,0 +1,19 @@
+package util
+
+// Constants for all user roles. The service role belongs to the non-human identities of the bank,
+// like the owner of the settlement accounts, and grants no privileges.
+const (
+	DepositorRole = "depositor"
+	BankerRole    = "banker"
+	AdminRole     = "admin"
+	ServiceRole   = "service"
+)
+
+// IsStaffRole reports whether the role belongs to the bank staff
+func IsStaffRole(role string) bool {
+	switch role {
+	case BankerRole, AdminRole:
+		return true
+	}
+	return false
+}